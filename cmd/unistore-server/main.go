@@ -18,7 +18,6 @@ import (
 	"fmt"
 	"net"
 	"net/http"
-	_ "net/http/pprof"
 	"os"
 	"os/signal"
 	"runtime"
@@ -32,6 +31,7 @@ import (
 	"github.com/pingcap/badger"
 	"github.com/pingcap/badger/y"
 	"github.com/pingcap/kvproto/pkg/deadlock"
+	"github.com/pingcap/kvproto/pkg/diagnosticspb"
 	"github.com/pingcap/kvproto/pkg/tikvpb"
 	"github.com/pingcap/log"
 	"github.com/pingcap/tidb/store/mockstore/unistore/pd"
@@ -146,17 +146,15 @@ func main() {
 	tikvpb.RegisterTikvServer(grpcServer, tikvServer)
 	listenAddr := conf.Server.StoreAddr[strings.IndexByte(conf.Server.StoreAddr, ':'):]
 	l, err := net.Listen("tcp", listenAddr)
-	deadlock.RegisterDeadlockServer(grpcServer, tikvServer)
 	if err != nil {
 		log.S().Fatal(err)
 	}
+	deadlock.RegisterDeadlockServer(grpcServer, tikvServer)
+	diagnosticspb.RegisterDiagnosticsServer(grpcServer, server.NewDiagnosticsServer(conf))
 	handleSignal(grpcServer)
 	go func() {
 		log.S().Infof("listening on %v", conf.Server.StatusAddr)
-		http.HandleFunc("/status", func(writer http.ResponseWriter, request *http.Request) {
-			writer.WriteHeader(http.StatusOK)
-		})
-		err := http.ListenAndServe(conf.Server.StatusAddr, nil)
+		err := http.ListenAndServe(conf.Server.StatusAddr, server.NewStatusHandler(conf))
 		if err != nil {
 			log.S().Fatal(err)
 		}
@@ -25,6 +25,7 @@ import (
 type Config struct {
 	config.Config
 	RaftStore RaftStore `toml:"raftstore"` // RaftStore configs
+	GC        GC        `toml:"gc"`        // GC configs
 }
 
 // RaftStore is the config for raft store.
@@ -35,6 +36,15 @@ type RaftStore struct {
 	RaftHeartbeatTicks       int    `toml:"raft-heartbeat-ticks"`        // raft-heartbeat-ticks times
 	RaftElectionTimeoutTicks int    `toml:"raft-election-timeout-ticks"` // raft-election-timeout-ticks times
 	CustomRaftLog            bool   `toml:"custom-raft-log"`
+	// Labels are reported to PD with the store, PD uses them to place the replicas.
+	Labels map[string]string `toml:"labels"`
+}
+
+// GC is the config for GC.
+type GC struct {
+	// Interval to fetch the GC safe point from PD, "0s" disables the GC worker
+	// and the safe point is only updated by KvGC requests.
+	SafePointCheckInterval string `toml:"safe-point-check-interval"`
 }
 
 // ParseCompression parses the string s and returns a compression type.
@@ -63,6 +73,9 @@ var DefaultConf = Config{
 		RaftElectionTimeoutTicks: 10,
 		CustomRaftLog:            true,
 	},
+	GC: GC{
+		SafePointCheckInterval: "10s",
+	},
 }
 
 // ParseDuration parses duration argument string.
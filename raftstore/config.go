@@ -135,6 +135,17 @@ type Config struct {
 
 	ConcurrentSendSnapLimit uint64
 	ConcurrentRecvSnapLimit uint64
+	// Max number of snapshots generated at the same time.
+	ConcurrentGenSnapLimit uint64
+
+	// Reject new prewrites and pessimistic locks of a region when it has more
+	// committed entries than this to apply. 0 disables the check.
+	FlowControlMaxApplyLag uint64
+	// The backoff hint returned with the ServerIsBusy error of flow control.
+	FlowControlBackoff time.Duration
+	// Reject new prewrites and pessimistic locks of a region when its approximate
+	// size exceeds this, which means the region fails to split. 0 disables the check.
+	FlowControlRegionSizeQuota uint64
 
 	GrpcInitialWindowSize uint64
 	GrpcKeepAliveTime     time.Duration
@@ -237,6 +248,9 @@ func NewDefaultConfig() *Config {
 		StoreMaxBatchSize:        1024,
 		ConcurrentSendSnapLimit:  32,
 		ConcurrentRecvSnapLimit:  32,
+		ConcurrentGenSnapLimit:   4,
+		FlowControlMaxApplyLag:   4096,
+		FlowControlBackoff:       100 * time.Millisecond,
 		GrpcInitialWindowSize:    2 * 1024 * 1024,
 		GrpcKeepAliveTime:        3 * time.Second,
 		GrpcKeepAliveTimeout:     60 * time.Second,
@@ -388,6 +388,24 @@ func deleteRange(db *mvcc.DBBundle, startKey, endKey []byte) error {
 	return deleteLocksInBatch(db, keys, delRangeBatchSize)
 }
 
+// UnsafeDestroyRange drops the data, the rollback and op lock records and the locks of the keys in
+// [startKey, endKey) without MVCC deletes and without going through raft. The tables that only contain keys in
+// the range are dropped, then the keys left in the partly covered tables are deleted.
+func UnsafeDestroyRange(db *mvcc.DBBundle, startKey, endKey []byte) error {
+	if len(startKey) == 0 || len(endKey) == 0 || bytes.Compare(startKey, endKey) >= 0 {
+		return errors.Errorf("invalid range [%x, %x)", startKey, endKey)
+	}
+	// The rollback and op lock records are stored as extra keys whose first byte is incremented.
+	extraStartKey := append([]byte{startKey[0] + 1}, startKey[1:]...)
+	extraEndKey := append([]byte{endKey[0] + 1}, endKey[1:]...)
+	deleteAllFilesInRange(db, startKey, endKey)
+	deleteAllFilesInRange(db, extraStartKey, extraEndKey)
+	if err := deleteRange(db, startKey, endKey); err != nil {
+		return err
+	}
+	return deleteRange(db, extraStartKey, extraEndKey)
+}
+
 func collectRangeKeys(it *badger.Iterator, startKey, endKey []byte, keys []y.Key) []y.Key {
 	if len(endKey) == 0 {
 		panic("invalid end key")
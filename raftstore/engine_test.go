@@ -0,0 +1,86 @@
+// Copyright 2019-present PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package raftstore
+
+import (
+	"io/ioutil"
+	"math"
+	"os"
+	"testing"
+
+	"github.com/pingcap/badger"
+	"github.com/pingcap/badger/options"
+	"github.com/pingcap/badger/y"
+	"github.com/pingcap/tidb/store/mockstore/unistore/tikv/mvcc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ingestTestTable builds a table of the keys in order and ingests it.
+func ingestTestTable(t *testing.T, db *mvcc.DBBundle, dir string, keys ...[]byte) {
+	file, err := ioutil.TempFile(dir, "ingest_convert_*.sst")
+	require.Nil(t, err)
+	builder := db.DB.NewExternalTableBuilder(file, options.None, nil)
+	builder.SetIsManaged()
+	for _, key := range keys {
+		require.Nil(t, builder.Add(y.KeyWithTs(key, 100), y.ValueStruct{
+			Value:    key,
+			UserMeta: mvcc.NewDBUserMeta(90, 100),
+		}))
+	}
+	_, err = builder.Finish()
+	require.Nil(t, err)
+	_, err = db.DB.IngestExternalFiles([]badger.ExternalTableSpec{{Filename: file.Name()}})
+	require.Nil(t, err)
+}
+
+func TestUnsafeDestroyRange(t *testing.T) {
+	dir, err := ioutil.TempDir("", "unsafe_destroy_range")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+	db := openDBBundle(t, dir)
+	defer db.DB.Close()
+
+	rollbackKey := mvcc.EncodeExtraTxnStatusKey([]byte("tb1"), 90)
+	// The first and the last tables are partly covered by the range [tb, tc), the second one is fully covered.
+	ingestTestTable(t, db, dir, []byte("ta"), []byte("tb1"))
+	ingestTestTable(t, db, dir, []byte("tb2"), []byte("tb3"))
+	ingestTestTable(t, db, dir, []byte("tc"), rollbackKey)
+	require.Len(t, db.DB.Tables(), 3)
+	db.LockStore.Put([]byte("tb1"), []byte("lock"))
+	db.LockStore.Put([]byte("tc"), []byte("lock"))
+
+	startKey, endKey := []byte("tb"), []byte("tc")
+	require.Nil(t, UnsafeDestroyRange(db, startKey, endKey))
+	assert.Equal(t, "tb", string(startKey))
+	assert.Equal(t, "tc", string(endKey))
+	assert.Len(t, db.DB.Tables(), 2)
+
+	txn := db.DB.NewTransaction(false)
+	txn.SetReadTS(math.MaxUint64)
+	defer txn.Discard()
+	for _, key := range []string{"ta", "tc"} {
+		_, err = txn.Get([]byte(key))
+		assert.Nil(t, err, key)
+	}
+	for _, key := range [][]byte{[]byte("tb1"), []byte("tb2"), []byte("tb3"), rollbackKey} {
+		_, err = txn.Get(key)
+		assert.Equal(t, badger.ErrKeyNotFound, err, "%q", key)
+	}
+	assert.Nil(t, db.LockStore.Get([]byte("tb1"), nil))
+	assert.NotNil(t, db.LockStore.Get([]byte("tc"), nil))
+
+	assert.NotNil(t, UnsafeDestroyRange(db, []byte("tc"), []byte("tb")))
+	assert.NotNil(t, UnsafeDestroyRange(db, []byte("tb"), nil))
+}
@@ -0,0 +1,112 @@
+// Copyright 2019-present PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package raftstore
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/ngaut/unistore/raftstore/raftlog"
+	"github.com/pingcap/badger"
+	"github.com/pingcap/kvproto/pkg/raft_cmdpb"
+	tidbconfig "github.com/pingcap/tidb/store/mockstore/unistore/config"
+)
+
+const flowControlEngineCheckInterval = time.Second
+
+// flowController rejects the writes that add new locks when the region has too many entries to apply, the
+// region grows too large without being split or the engine is about to stall, so a hot region returns
+// ServerIsBusy and lets the client back off instead of piling up unapplied entries in memory. Commit and
+// rollback are never rejected, they finish the transactions in flight. The rejections are counted by reason
+// in flowControlRejected.
+type flowController struct {
+	cfg       *Config
+	engineCfg *tidbconfig.Engine
+	kv        *badger.DB
+
+	// nextEngineCheck is the unix nano time to refresh l0Stall.
+	nextEngineCheck int64
+	l0Stall         uint32
+}
+
+func newFlowController(cfg *Config, engineCfg *tidbconfig.Engine, kv *badger.DB) *flowController {
+	return &flowController{
+		cfg:       cfg,
+		engineCfg: engineCfg,
+		kv:        kv,
+	}
+}
+
+// check returns ErrServerIsBusy if the write should be throttled, applyLag is the number of committed entries
+// of the region that are not applied yet, regionSize is the approximate size of the region, 0 if unknown.
+func (fc *flowController) check(regionID, applyLag, regionSize uint64, rlog raftlog.RaftLog) error {
+	if fc == nil || !addsLocks(rlog) {
+		return nil
+	}
+	backoffMs := uint64(fc.cfg.FlowControlBackoff / time.Millisecond)
+	if fc.cfg.FlowControlMaxApplyLag > 0 && applyLag > fc.cfg.FlowControlMaxApplyLag {
+		flowControlRejected.WithLabelValues("apply_lag").Inc()
+		return &ErrServerIsBusy{
+			Reason:    fmt.Sprintf("region %d has %d entries to apply", regionID, applyLag),
+			BackoffMs: backoffMs,
+		}
+	}
+	if fc.cfg.FlowControlRegionSizeQuota > 0 && regionSize > fc.cfg.FlowControlRegionSizeQuota {
+		flowControlRejected.WithLabelValues("region_size").Inc()
+		return &ErrServerIsBusy{
+			Reason:    fmt.Sprintf("region %d size %d exceeds the quota %d", regionID, regionSize, fc.cfg.FlowControlRegionSizeQuota),
+			BackoffMs: backoffMs,
+		}
+	}
+	if fc.engineStalled() {
+		flowControlRejected.WithLabelValues("l0_stall").Inc()
+		return &ErrServerIsBusy{
+			Reason:    "too many level 0 tables",
+			BackoffMs: backoffMs,
+		}
+	}
+	return nil
+}
+
+func (fc *flowController) engineStalled() bool {
+	now := time.Now().UnixNano()
+	next := atomic.LoadInt64(&fc.nextEngineCheck)
+	if now >= next && atomic.CompareAndSwapInt64(&fc.nextEngineCheck, next, now+int64(flowControlEngineCheckInterval)) {
+		var stall uint32
+		if newEngineBacklog(fc.kv.Tables(), fc.engineCfg).l0Stall {
+			stall = 1
+		}
+		atomic.StoreUint32(&fc.l0Stall, stall)
+	}
+	return atomic.LoadUint32(&fc.l0Stall) == 1
+}
+
+// addsLocks returns whether the raft log is a prewrite or a pessimistic lock.
+func addsLocks(rlog raftlog.RaftLog) bool {
+	if custom, ok := rlog.(*raftlog.CustomRaftLog); ok {
+		tp := custom.Type()
+		return tp == raftlog.TypePrewrite || tp == raftlog.TypePessimisticLock
+	}
+	req := rlog.GetRaftCmdRequest()
+	if req == nil || req.AdminRequest != nil {
+		return false
+	}
+	for _, r := range req.Requests {
+		if r.CmdType == raft_cmdpb.CmdType_Put && r.Put.Cf == CFLock {
+			return true
+		}
+	}
+	return false
+}
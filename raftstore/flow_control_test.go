@@ -0,0 +1,75 @@
+// Copyright 2019-present PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package raftstore
+
+import (
+	"math"
+	"testing"
+
+	"github.com/ngaut/unistore/raftstore/raftlog"
+	"github.com/pingcap/kvproto/pkg/kvrpcpb"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/kvproto/pkg/raft_cmdpb"
+	"github.com/pingcap/tidb/store/mockstore/unistore/tikv/mvcc"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFlowControl(t *testing.T) {
+	ctx := &kvrpcpb.Context{
+		RegionId:    1,
+		RegionEpoch: &metapb.RegionEpoch{Version: 1, ConfVer: 1},
+		Peer:        &metapb.Peer{Id: 2, StoreId: 3},
+	}
+	prewrite := NewCustomWriteBatch(10, 0, ctx).(*customWriteBatch)
+	prewrite.Prewrite([]byte("k"), &mvcc.Lock{})
+	commit := NewCustomWriteBatch(10, 11, ctx).(*customWriteBatch)
+	commit.Commit([]byte("k"), &mvcc.Lock{})
+	read := raftlog.NewRequest(&raft_cmdpb.RaftCmdRequest{
+		Requests: []*raft_cmdpb.Request{{CmdType: raft_cmdpb.CmdType_Snap}},
+	})
+
+	cfg := NewDefaultConfig()
+	cfg.FlowControlMaxApplyLag = 10
+	fc := newFlowController(cfg, nil, nil)
+	// Skip the engine check which needs a real engine.
+	fc.nextEngineCheck = math.MaxInt64
+	rejected := func(reason string) float64 {
+		return testutil.ToFloat64(flowControlRejected.WithLabelValues(reason))
+	}
+	applyLagRejected, sizeRejected, l0Rejected := rejected("apply_lag"), rejected("region_size"), rejected("l0_stall")
+
+	assert.Nil(t, fc.check(1, 10, 0, prewrite.builder.Build()))
+	err := fc.check(1, 11, 0, prewrite.builder.Build())
+	assert.IsType(t, &ErrServerIsBusy{}, err)
+	assert.Equal(t, uint64(100), err.(*ErrServerIsBusy).BackoffMs)
+	assert.Nil(t, fc.check(1, 11, 0, commit.builder.Build()))
+	assert.Nil(t, fc.check(1, 11, 0, read))
+	assert.Equal(t, applyLagRejected+1, rejected("apply_lag"))
+
+	cfg.FlowControlRegionSizeQuota = 1024
+	assert.Nil(t, fc.check(1, 0, 1024, prewrite.builder.Build()))
+	assert.IsType(t, &ErrServerIsBusy{}, fc.check(1, 0, 1025, prewrite.builder.Build()))
+	assert.Nil(t, fc.check(1, 0, 1025, commit.builder.Build()))
+	assert.Equal(t, sizeRejected+1, rejected("region_size"))
+	cfg.FlowControlRegionSizeQuota = 0
+
+	fc.l0Stall = 1
+	assert.IsType(t, &ErrServerIsBusy{}, fc.check(1, 0, 0, prewrite.builder.Build()))
+	assert.Equal(t, l0Rejected+1, rejected("l0_stall"))
+
+	// A nil controller never throttles.
+	var nilFC *flowController
+	assert.Nil(t, nilFC.check(1, 11, 0, prewrite.builder.Build()))
+}
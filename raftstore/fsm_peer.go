@@ -358,6 +358,7 @@ func (d *peerMsgHandler) onRaftBaseTick() {
 	}
 	// TODO: make Tick returns bool to indicate if there is ready.
 	d.peer.RaftGroup.Tick()
+	d.peer.retryReplicaReads(d.ctx.cfg, time.Now())
 	d.hasReady = d.peer.RaftGroup.HasReady()
 	d.ticker.schedule(PeerTickRaft)
 }
@@ -1006,7 +1007,7 @@ func (d *peerMsgHandler) preProposeRaftCommand(rlog raftlog.RaftLog) (*raft_cmdp
 	// Check whether the store has the right peer to handle the request.
 	regionID := d.regionID()
 	leaderID := d.peer.LeaderID()
-	if !d.peer.IsLeader() {
+	if !d.peer.IsLeader() && !isReplicaRead(req) {
 		leader := d.peer.getPeerFromCache(leaderID)
 		return nil, &ErrNotLeader{regionID, leader}
 	}
@@ -1049,6 +1050,13 @@ func (d *peerMsgHandler) proposeRaftCommand(rlog raftlog.RaftLog, cb *Callback)
 		return
 	}
 	msg := rlog.GetRaftCmdRequest()
+	// Read-only commands write nothing, they are never throttled by the write flow control.
+	if !isReadOnly(msg) {
+		if err := d.checkFlowControl(rlog); err != nil {
+			cb.Done(ErrResp(err))
+			return
+		}
+	}
 	if err := d.checkMergeProposal(msg); err != nil {
 		log.S().Warnf("%s failed to process merge, message %s, err %v", d.tag(), msg, err)
 		cb.Done(ErrResp(err))
@@ -1070,6 +1078,18 @@ func (d *peerMsgHandler) proposeRaftCommand(rlog raftlog.RaftLog, cb *Callback)
 	// we will call the callback with timeout error.
 }
 
+func (d *peerMsgHandler) checkFlowControl(rlog raftlog.RaftLog) error {
+	var applyLag uint64
+	if appliedIdx := d.peer.Store().AppliedIndex(); d.peer.LastApplyingIdx > appliedIdx {
+		applyLag = d.peer.LastApplyingIdx - appliedIdx
+	}
+	var regionSize uint64
+	if d.peer.ApproximateSize != nil {
+		regionSize = *d.peer.ApproximateSize
+	}
+	return d.ctx.flowCtl.check(d.regionID(), applyLag, regionSize, rlog)
+}
+
 func (d *peerMsgHandler) findSiblingRegion() *metapb.Region {
 	var start []byte
 	var skipFirst bool
@@ -95,6 +95,7 @@ type GlobalContext struct {
 	pdClient              pd.Client
 	peerEventObserver     PeerEventObserver
 	globalStats           *storeStats
+	flowCtl               *flowController
 }
 
 // StoreContext represents a store context.
@@ -416,6 +417,7 @@ func (bs *raftBatchSystem) start(
 		pdClient:              pdClient,
 		peerEventObserver:     observer,
 		globalStats:           new(storeStats),
+		flowCtl:               newFlowController(cfg, &bs.globalCfg.Engine, engines.kv.DB),
 	}
 	regionPeers, err := bs.loadPeers()
 	if err != nil {
@@ -452,10 +454,10 @@ func (bs *raftBatchSystem) startWorkers(peers []*peerFsm) {
 	engines := ctx.engine
 	cfg := ctx.cfg
 	workers.splitCheckWorker.start(newSplitCheckRunner(engines.kv.DB, router, cfg.SplitCheck))
-	workers.regionWorker.start(newRegionTaskHandler(bs.globalCfg, engines, ctx.snapMgr, cfg.SnapApplyBatchSize, cfg.CleanStalePeerDelay))
+	workers.regionWorker.start(newRegionTaskHandler(bs.globalCfg, engines, ctx.snapMgr, cfg.SnapApplyBatchSize, cfg.CleanStalePeerDelay, cfg.ConcurrentGenSnapLimit))
 	workers.raftLogGCWorker.start(&raftLogGCTaskHandler{})
 	workers.compactWorker.start(&compactTaskHandler{engine: engines.kv.DB})
-	workers.pdWorker.start(newPDTaskHandler(ctx.store.Id, ctx.pdClient, bs.router))
+	workers.pdWorker.start(newPDTaskHandler(ctx.store.Id, ctx.pdClient, bs.router, &bs.globalCfg.Engine))
 	workers.computeHashWorker.start(&computeHashTaskHandler{router: bs.router})
 }
 
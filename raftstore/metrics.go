@@ -0,0 +1,46 @@
+// Copyright 2019-present PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package raftstore
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var latchWaiters = prometheus.NewGauge(
+	prometheus.GaugeOpts{
+		Namespace: "unistore",
+		Subsystem: "raft",
+		Name:      "latch_waiters",
+		Help:      "Number of commands queued for latches.",
+	})
+
+var flowControlRejected = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: "unistore",
+		Subsystem: "raft",
+		Name:      "flow_control_rejected_total",
+		Help:      "Number of writes rejected by flow control.",
+	}, []string{"reason"})
+
+var engineCompactionScore = prometheus.NewGauge(
+	prometheus.GaugeOpts{
+		Namespace: "unistore",
+		Subsystem: "raft",
+		Name:      "engine_compaction_score",
+		Help:      "Sum of the compaction scores of the kv engine levels that need to be compacted.",
+	})
+
+func init() {
+	prometheus.MustRegister(latchWaiters)
+	prometheus.MustRegister(flowControlRejected)
+	prometheus.MustRegister(engineCompactionScore)
+}
@@ -15,33 +15,39 @@ package raftstore
 
 import (
 	"context"
+	"math"
 	"time"
 
 	"github.com/ngaut/unistore/raftstore/raftlog"
+	"github.com/pingcap/badger"
 	"github.com/pingcap/kvproto/pkg/metapb"
 	"github.com/pingcap/kvproto/pkg/pdpb"
 	"github.com/pingcap/kvproto/pkg/raft_cmdpb"
 	"github.com/pingcap/kvproto/pkg/raft_serverpb"
 	"github.com/pingcap/log"
+	tidbconfig "github.com/pingcap/tidb/store/mockstore/unistore/config"
 	"github.com/pingcap/tidb/store/mockstore/unistore/pd"
 	"github.com/shirou/gopsutil/disk"
+	"go.uber.org/zap"
 )
 
 type pdTaskHandler struct {
-	storeID  uint64
-	pdClient pd.Client
-	router   *router
+	storeID   uint64
+	pdClient  pd.Client
+	router    *router
+	engineCfg *tidbconfig.Engine
 
 	// statistics
 	storeStats storeStatistics
 	peerStats  map[uint64]*peerStatistics
 }
 
-func newPDTaskHandler(storeID uint64, pdClient pd.Client, router *router) *pdTaskHandler {
+func newPDTaskHandler(storeID uint64, pdClient pd.Client, router *router, engineCfg *tidbconfig.Engine) *pdTaskHandler {
 	return &pdTaskHandler{
 		storeID:   storeID,
 		pdClient:  pdClient,
 		router:    router,
+		engineCfg: engineCfg,
 		peerStats: make(map[uint64]*peerStatistics),
 	}
 }
@@ -131,6 +137,7 @@ func (r *pdTaskHandler) onAskBatchSplit(t *pdAskBatchSplitTask) {
 	resp, err := r.pdClient.AskBatchSplit(context.TODO(), t.region, len(t.splitKeys))
 	if err != nil {
 		log.S().Error(err)
+		t.callback.Done(ErrResp(err))
 		return
 	}
 	srs := make([]*raft_cmdpb.SplitRequest, len(resp.Ids))
@@ -225,6 +232,18 @@ func (r *pdTaskHandler) onStoreHeartbeat(t *pdStoreHeartbeatTask) {
 	r.storeStats.lastTotalReadKeys = r.storeStats.totalReadKeys
 	r.storeStats.lastReport = time.Now()
 
+	backlog := newEngineBacklog(t.engine.Tables(), r.engineCfg)
+	if backlog.l0Stall {
+		// PD has no dedicated field for background work, IsBusy keeps it from scheduling more to this store.
+		t.stats.IsBusy = true
+	}
+	engineCompactionScore.Set(backlog.compactionScore)
+	if backlog.compactionScore > 0 {
+		log.Debug("engine is falling behind on compaction", zap.Uint64("store id", r.storeID),
+			zap.Float64("compaction score", backlog.compactionScore), zap.Int("L0 tables", backlog.l0Tables),
+			zap.Bool("L0 stall", backlog.l0Stall))
+	}
+
 	if err := r.pdClient.StoreHeartbeat(context.TODO(), t.stats); err != nil {
 		log.S().Error(err)
 	}
@@ -334,6 +353,53 @@ type storeStatistics struct {
 	lastReport         time.Time
 }
 
+// L0Stalled returns true if the number of level 0 tables reaches the write stall threshold of the engine.
+func L0Stalled(cfg *tidbconfig.Engine, l0Tables int) bool {
+	return cfg.NumL0TablesStall > 0 && l0Tables >= cfg.NumL0TablesStall
+}
+
+// engineBacklog describes how far the kv engine is behind on background compaction.
+type engineBacklog struct {
+	// compactionScore is the sum of the scores of all levels that need to be compacted.
+	compactionScore float64
+	l0Tables        int
+	// l0Stall is true when the number of L0 tables reaches the write stall threshold.
+	l0Stall bool
+}
+
+// newEngineBacklog estimates the engine backlog from the table list. The size of a level is estimated by
+// the table count because badger doesn't expose the table sizes.
+func newEngineBacklog(tables []badger.TableInfo, cfg *tidbconfig.Engine) engineBacklog {
+	var backlog engineBacklog
+	if cfg == nil {
+		return backlog
+	}
+	var levelTables []int
+	for _, t := range tables {
+		for len(levelTables) <= t.Level {
+			levelTables = append(levelTables, 0)
+		}
+		levelTables[t.Level]++
+	}
+	for level, cnt := range levelTables {
+		var score float64
+		if level == 0 {
+			backlog.l0Tables = cnt
+			backlog.l0Stall = L0Stalled(cfg, cnt)
+			if cfg.NumL0Tables > 0 {
+				score = float64(cnt) / float64(cfg.NumL0Tables)
+			}
+		} else if cfg.L1Size > 0 {
+			targetSize := float64(cfg.L1Size) * math.Pow(10, float64(level-1))
+			score = float64(cnt) * float64(cfg.MaxTableSize) / targetSize
+		}
+		if score >= 1 {
+			backlog.compactionScore += score
+		}
+	}
+	return backlog
+}
+
 type peerStatistics struct {
 	readBytes        uint64
 	readKeys         uint64
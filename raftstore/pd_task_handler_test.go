@@ -0,0 +1,63 @@
+// Copyright 2019-present PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package raftstore
+
+import (
+	"testing"
+
+	"github.com/ngaut/unistore/config"
+	"github.com/pingcap/badger"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEngineBacklog(t *testing.T) {
+	cfg := config.DefaultConf.Engine
+	cfg.NumL0Tables = 4
+	cfg.NumL0TablesStall = 8
+	cfg.L1Size = 64
+	cfg.MaxTableSize = 8
+
+	newTables := func(level, cnt int) []badger.TableInfo {
+		tables := make([]badger.TableInfo, cnt)
+		for i := range tables {
+			tables[i].Level = level
+		}
+		return tables
+	}
+
+	backlog := newEngineBacklog(nil, &cfg)
+	assert.Equal(t, engineBacklog{}, backlog)
+
+	// L0 below the compaction trigger and L1 under its target size.
+	tables := append(newTables(0, 2), newTables(1, 4)...)
+	backlog = newEngineBacklog(tables, &cfg)
+	assert.Equal(t, 2, backlog.l0Tables)
+	assert.False(t, backlog.l0Stall)
+	assert.Equal(t, 0.0, backlog.compactionScore)
+
+	// L0 stalled and L1 twice its target size.
+	tables = append(newTables(0, 8), newTables(1, 16)...)
+	backlog = newEngineBacklog(tables, &cfg)
+	assert.Equal(t, 8, backlog.l0Tables)
+	assert.True(t, backlog.l0Stall)
+	assert.Equal(t, 4.0, backlog.compactionScore)
+
+	assert.False(t, L0Stalled(&cfg, 7))
+	assert.True(t, L0Stalled(&cfg, 8))
+	cfg.NumL0TablesStall = 0
+	assert.False(t, L0Stalled(&cfg, 8))
+
+	// Without config nothing is reported.
+	assert.Equal(t, engineBacklog{}, newEngineBacklog(tables, nil))
+}
@@ -60,6 +60,10 @@ type ReadIndexRequest struct {
 	id             uint64
 	cmds           []*ReqCbPair
 	renewLeaseTime *time.Time
+	// readIndex is the index returned by the leader for a replica read, 0 means it's not returned yet.
+	readIndex uint64
+	// retries is the number of times the replica read is resent to the leader.
+	retries int
 }
 
 // NewReadIndexRequest creates a new ReadIndexRequest.
@@ -82,6 +86,10 @@ type ReadIndexQueue struct {
 	idAllocator uint64
 	reads       []*ReadIndexRequest
 	readyCnt    int
+	// replica is true if the reads are replica reads sent by a follower.
+	replica bool
+	// staleID is the largest id of the reads cleared on role change, their read states are ignored.
+	staleID uint64
 }
 
 // PopFront pops the front ReadIndexRequest from the ReadIndex queue.
@@ -112,6 +120,89 @@ func (q *ReadIndexQueue) NextID() uint64 {
 	return q.idAllocator
 }
 
+// advanceReplicaReads sets the read index of the replica reads by the read states. The follower may lose
+// ReadIndexResp, so the read states are not guaranteed to match the queue in order, all the reads before
+// the matched one share its read index.
+func (q *ReadIndexQueue) advanceReplicaReads(states []raft.ReadState) {
+	for _, state := range states {
+		for i, read := range q.reads {
+			if !bytes.Equal(state.RequestCtx, read.binaryID()) {
+				continue
+			}
+			for _, r := range q.reads[:i+1] {
+				if r.readIndex == 0 {
+					r.readIndex = state.Index
+				}
+			}
+			break
+		}
+	}
+}
+
+// isStale returns true if the read state belongs to a read cleared on role change.
+func (q *ReadIndexQueue) isStale(state raft.ReadState) bool {
+	return len(state.RequestCtx) == 8 && binary.BigEndian.Uint64(state.RequestCtx) <= q.staleID
+}
+
+// switchRole clears all the reads queued in the previous role, the read states of a leader can't answer
+// replica reads and vice versa.
+func (q *ReadIndexQueue) switchRole(replica bool, term uint64) {
+	for _, read := range q.reads {
+		for _, reqCbPair := range read.cmds {
+			NotifyStaleReq(term, reqCbPair.Cb)
+		}
+		read.cmds = nil
+	}
+	q.reads = nil
+	q.readyCnt = 0
+	q.staleID = q.idAllocator
+	q.replica = replica
+}
+
+// clearUnindexedReplicaReads clears the replica reads whose read index is not returned yet, they are sent
+// to the previous leader and will never be responded.
+func (q *ReadIndexQueue) clearUnindexedReplicaReads(term uint64) {
+	reads := q.reads[:0]
+	for _, read := range q.reads {
+		if read.readIndex != 0 {
+			reads = append(reads, read)
+			continue
+		}
+		for _, reqCbPair := range read.cmds {
+			NotifyStaleReq(term, reqCbPair.Cb)
+		}
+		read.cmds = nil
+	}
+	q.reads = reads
+}
+
+// replicaReadMaxRetries bounds the number of times a replica read is resent to the leader.
+const replicaReadMaxRetries = 3
+
+// retryReplicaReads sends the replica reads whose read index is not returned again, the ReadIndexResp may
+// be lost. A read is resent at most replicaReadMaxRetries times and only if there is a leader to send it to.
+// The reads sent before `deadline` are responded with stale command instead.
+func (q *ReadIndexQueue) retryReplicaReads(deadline time.Time, term uint64, hasLeader bool, send func(ctx []byte)) {
+	reads := q.reads[:0]
+	for _, read := range q.reads {
+		if read.readIndex == 0 {
+			if read.renewLeaseTime.Before(deadline) {
+				for _, reqCbPair := range read.cmds {
+					NotifyStaleReq(term, reqCbPair.Cb)
+				}
+				read.cmds = nil
+				continue
+			}
+			if hasLeader && read.retries < replicaReadMaxRetries {
+				read.retries++
+				send(read.binaryID())
+			}
+		}
+		reads = append(reads, read)
+	}
+	q.reads = reads
+}
+
 // ClearUncommitted clears the uncommitted ReadIndex requests.
 func (q *ReadIndexQueue) ClearUncommitted(term uint64) {
 	uncommitted := q.reads[q.readyCnt:]
@@ -1128,8 +1219,17 @@ func (p *Peer) HandleRaftReadyApply(kv *mvcc.DBBundle, applyMsgs *applyMsgs, rea
 // ApplyReads applies reads.
 func (p *Peer) ApplyReads(kv *mvcc.DBBundle, ready *raft.Ready) {
 	var proposeTime *time.Time
-	if p.readyToHandleRead() {
+	if ready.SoftState != nil {
+		p.checkReadsRole()
+	}
+	if !p.IsLeader() {
+		p.pendingReads.advanceReplicaReads(ready.ReadStates)
+		p.handleReplicaReads(kv)
+	} else if p.readyToHandleRead() {
 		for _, state := range ready.ReadStates {
+			if p.pendingReads.isStale(state) {
+				continue
+			}
 			read := p.pendingReads.PopFront()
 			if read == nil {
 				panic("read should exist")
@@ -1146,6 +1246,9 @@ func (p *Peer) ApplyReads(kv *mvcc.DBBundle, ready *raft.Ready) {
 		}
 	} else {
 		for _, state := range ready.ReadStates {
+			if p.pendingReads.isStale(state) {
+				continue
+			}
 			read := p.pendingReads.reads[p.pendingReads.readyCnt]
 			if !bytes.Equal(state.RequestCtx, read.binaryID()) {
 				panic(fmt.Sprintf("request ctx: %v not equal to read id: %v", state.RequestCtx, read.binaryID()))
@@ -1158,8 +1261,13 @@ func (p *Peer) ApplyReads(kv *mvcc.DBBundle, ready *raft.Ready) {
 	// Note that only after handle read_states can we identify what requests are
 	// actually stale.
 	if ready.SoftState != nil {
-		// all uncommitted reads will be dropped silently in raft.
-		p.pendingReads.ClearUncommitted(p.Term())
+		if p.pendingReads.replica {
+			// The replica reads sent to the previous leader will never be responded.
+			p.pendingReads.clearUnindexedReplicaReads(p.Term())
+		} else {
+			// all uncommitted reads will be dropped silently in raft.
+			p.pendingReads.ClearUncommitted(p.Term())
+		}
 	}
 
 	if proposeTime != nil {
@@ -1172,6 +1280,42 @@ func (p *Peer) ApplyReads(kv *mvcc.DBBundle, ready *raft.Ready) {
 	}
 }
 
+// checkReadsRole clears the pending reads if they are queued in a different role.
+func (p *Peer) checkReadsRole() {
+	isLeader := p.IsLeader()
+	if p.pendingReads.replica == isLeader {
+		p.pendingReads.switchRole(!isLeader, p.Term())
+	}
+}
+
+// retryReplicaReads resends the replica reads that are not responded by the leader, and gives up the ones
+// pending longer than an election timeout.
+func (p *Peer) retryReplicaReads(cfg *Config, now time.Time) {
+	if p.IsLeader() || !p.pendingReads.replica {
+		return
+	}
+	timeout := cfg.RaftBaseTickInterval * time.Duration(cfg.RaftElectionTimeoutTicks)
+	hasLeader := p.LeaderID() != raft.None
+	p.pendingReads.retryReplicaReads(now.Add(-timeout), p.Term(), hasLeader, p.RaftGroup.ReadIndex)
+}
+
+// handleReplicaReads responds the replica reads whose read index has been applied.
+func (p *Peer) handleReplicaReads(kv *mvcc.DBBundle) {
+	appliedIndex := p.Store().AppliedIndex()
+	for len(p.pendingReads.reads) > 0 {
+		read := p.pendingReads.reads[0]
+		if read.readIndex == 0 || read.readIndex > appliedIndex {
+			return
+		}
+		p.pendingReads.PopFront()
+		for _, reqCb := range read.cmds {
+			resp := p.handleRead(kv, reqCb.Req, true)
+			reqCb.Cb.Done(resp)
+		}
+		read.cmds = nil
+	}
+}
+
 // PostApply returns a boolean value indicating whether the peer has ready.
 func (p *Peer) PostApply(kv *mvcc.DBBundle, applyState applyState, appliedIndexTerm uint64, merged bool, applyMetrics applyMetrics) bool {
 	hasReady := false
@@ -1216,6 +1360,10 @@ func (p *Peer) PostApply(kv *mvcc.DBBundle, applyState applyState, appliedIndexT
 		p.pendingReads.readyCnt = 0
 	}
 
+	if !p.IsLeader() {
+		p.handleReplicaReads(kv)
+	}
+
 	// Only leaders need to update applied_index_term.
 	if progressToBeUpdated && p.IsLeader() && !p.PendingRemove {
 		p.leaderChecker.appliedIndexTerm.Store(appliedIndexTerm)
@@ -1456,8 +1604,19 @@ func (p *Peer) readIndex(cfg *Config, req *raft_cmdpb.RaftCmdRequest, errResp *r
 
 	now := time.Now()
 	renewLeaseTime := &now
+	isLeader := p.IsLeader()
+	// When a replica cannot detect any leader, `MsgReadIndex` will be dropped, return an error directly
+	// instead of waiting for a response that never comes.
+	if !isLeader && p.LeaderID() == raft.None {
+		BindRespError(errResp, &ErrNotLeader{RegionID: p.regionID})
+		cb.Done(errResp)
+		return false
+	}
+	p.checkReadsRole()
 	readsLen := len(p.pendingReads.reads)
-	if readsLen > 0 {
+	// The read index of a replica read is returned after the request is sent to the leader, so a replica
+	// read can't be batched into an earlier one.
+	if isLeader && readsLen > 0 {
 		read := p.pendingReads.reads[readsLen-1]
 		if read.renewLeaseTime.Add(cfg.RaftStoreMaxLeaderLease).After(*renewLeaseTime) {
 			read.cmds = append(read.cmds, &ReqCbPair{Req: req, Cb: cb})
@@ -1476,7 +1635,7 @@ func (p *Peer) readIndex(cfg *Config, req *raft_cmdpb.RaftCmdRequest, errResp *r
 	pendingReadCount := p.RaftGroup.Raft.PendingReadCount()
 	readyReadCount := p.RaftGroup.Raft.ReadyReadCount()
 
-	if pendingReadCount == lastPendingReadCount && readyReadCount == lastReadyReadCount {
+	if isLeader && pendingReadCount == lastPendingReadCount && readyReadCount == lastReadyReadCount {
 		// The message gets dropped silently, can't be handled anymore.
 		NotifyStaleReq(p.Term(), cb)
 		return false
@@ -1487,7 +1646,7 @@ func (p *Peer) readIndex(cfg *Config, req *raft_cmdpb.RaftCmdRequest, errResp *r
 
 	// TimeoutNow has been sent out, so we need to propose explicitly to
 	// update leader lease.
-	if p.leaderLease.Inspect(renewLeaseTime) == LeaseStateSuspect {
+	if isLeader && p.leaderLease.Inspect(renewLeaseTime) == LeaseStateSuspect {
 		req := new(raft_cmdpb.RaftCmdRequest)
 		if index, err := p.ProposeNormal(cfg, raftlog.NewRequest(req)); err == nil {
 			meta := &ProposalMeta{
@@ -1755,6 +1914,27 @@ func (p *Peer) inspect(rlog raftlog.RaftLog) (RequestPolicy, error) {
 	return Inspect(p, req)
 }
 
+// isReplicaRead returns true if the request is a replica read that only contains read commands, only
+// such requests can be handled by a follower.
+func isReplicaRead(req *raft_cmdpb.RaftCmdRequest) bool {
+	return req.GetHeader().GetReplicaRead() && isReadOnly(req)
+}
+
+// isReadOnly returns true if the request only contains read commands.
+func isReadOnly(req *raft_cmdpb.RaftCmdRequest) bool {
+	if req.GetAdminRequest() != nil || len(req.GetRequests()) == 0 {
+		return false
+	}
+	for _, r := range req.GetRequests() {
+		switch r.CmdType {
+		case raft_cmdpb.CmdType_Get, raft_cmdpb.CmdType_Snap:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
 // Inspect returns a request policy with the given RaftCmdRequest.
 func Inspect(i RequestInspector, req *raft_cmdpb.RaftCmdRequest) (RequestPolicy, error) {
 	if req.AdminRequest != nil {
@@ -1788,7 +1968,7 @@ func Inspect(i RequestInspector, req *raft_cmdpb.RaftCmdRequest) (RequestPolicy,
 		return RequestPolicyProposeNormal, nil
 	}
 
-	if req.Header != nil && req.Header.ReadQuorum {
+	if req.Header != nil && (req.Header.ReadQuorum || req.Header.ReplicaRead) {
 		return RequestPolicyReadIndex, nil
 	}
 
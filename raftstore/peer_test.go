@@ -15,10 +15,12 @@ package raftstore
 
 import (
 	"testing"
+	"time"
 
 	"github.com/ngaut/unistore/raftstore/raftlog"
 	"github.com/pingcap/kvproto/pkg/raft_cmdpb"
 	"github.com/stretchr/testify/assert"
+	"github.com/zhangjinpeng1987/raft"
 )
 
 func TestGetSyncLogFromRequest(t *testing.T) {
@@ -190,6 +192,13 @@ func TestRequestInspector(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, inspectPolicy, RequestPolicyReadIndex)
 
+	// Replica read
+	req.Header = new(raft_cmdpb.RaftRequestHeader)
+	req.Header.ReplicaRead = true
+	inspectPolicy, err = inspector.inspect(req)
+	assert.Nil(t, err)
+	assert.Equal(t, inspectPolicy, RequestPolicyReadIndex)
+
 	// Err(_)
 	var errTbl []*raft_cmdpb.RaftCmdRequest
 	for _, op := range []raft_cmdpb.CmdType{raft_cmdpb.CmdType_Prewrite, raft_cmdpb.CmdType_Invalid} {
@@ -215,3 +224,128 @@ func TestRequestInspector(t *testing.T) {
 		assert.NotNil(t, err)
 	}
 }
+
+func TestAdvanceReplicaReads(t *testing.T) {
+	q := new(ReadIndexQueue)
+	for i := 0; i < 3; i++ {
+		q.reads = append(q.reads, NewReadIndexRequest(q.NextID(), nil, nil))
+	}
+	// The response of the first read is lost.
+	q.advanceReplicaReads([]raft.ReadState{{Index: 10, RequestCtx: q.reads[1].binaryID()}})
+	assert.Equal(t, uint64(10), q.reads[0].readIndex)
+	assert.Equal(t, uint64(10), q.reads[1].readIndex)
+	assert.Equal(t, uint64(0), q.reads[2].readIndex)
+
+	q.advanceReplicaReads([]raft.ReadState{{Index: 12, RequestCtx: q.reads[2].binaryID()}})
+	assert.Equal(t, uint64(10), q.reads[1].readIndex)
+	assert.Equal(t, uint64(12), q.reads[2].readIndex)
+}
+
+func newTestReplicaReads(q *ReadIndexQueue, sendTime time.Time, n int) []*Callback {
+	cbs := make([]*Callback, 0, n)
+	for i := 0; i < n; i++ {
+		cb := NewCallback()
+		cbs = append(cbs, cb)
+		q.reads = append(q.reads, NewReadIndexRequest(q.NextID(), []*ReqCbPair{{Cb: cb}}, &sendTime))
+	}
+	return cbs
+}
+
+func TestIsReplicaRead(t *testing.T) {
+	header := &raft_cmdpb.RaftRequestHeader{ReplicaRead: true}
+	get := &raft_cmdpb.Request{CmdType: raft_cmdpb.CmdType_Get}
+	put := &raft_cmdpb.Request{CmdType: raft_cmdpb.CmdType_Put}
+	assert.True(t, isReplicaRead(&raft_cmdpb.RaftCmdRequest{Header: header, Requests: []*raft_cmdpb.Request{get}}))
+	assert.False(t, isReplicaRead(&raft_cmdpb.RaftCmdRequest{Requests: []*raft_cmdpb.Request{get}}))
+	assert.False(t, isReplicaRead(&raft_cmdpb.RaftCmdRequest{Header: header}))
+	assert.False(t, isReplicaRead(&raft_cmdpb.RaftCmdRequest{Header: header, Requests: []*raft_cmdpb.Request{get, put}}))
+	assert.False(t, isReplicaRead(&raft_cmdpb.RaftCmdRequest{
+		Header:       header,
+		AdminRequest: &raft_cmdpb.AdminRequest{CmdType: raft_cmdpb.AdminCmdType_TransferLeader},
+	}))
+
+	// Read-only commands are exempted from the write flow control with or without replica read.
+	assert.True(t, isReadOnly(&raft_cmdpb.RaftCmdRequest{Requests: []*raft_cmdpb.Request{get}}))
+	assert.False(t, isReadOnly(&raft_cmdpb.RaftCmdRequest{Requests: []*raft_cmdpb.Request{get, put}}))
+	assert.False(t, isReadOnly(nil))
+}
+
+func TestReadIndexQueueSwitchRole(t *testing.T) {
+	q := new(ReadIndexQueue)
+	cbs := newTestReplicaReads(q, time.Now(), 2)
+	q.readyCnt = 1
+	q.switchRole(true, 5)
+	assert.True(t, q.replica)
+	assert.Len(t, q.reads, 0)
+	assert.Equal(t, 0, q.readyCnt)
+	for _, cb := range cbs {
+		cb.wg.Wait()
+		assert.NotNil(t, cb.resp.Header.Error.StaleCommand)
+	}
+
+	// The read states of the cleared reads are ignored.
+	newTestReplicaReads(q, time.Now(), 1)
+	assert.True(t, q.isStale(raft.ReadState{RequestCtx: NewReadIndexRequest(2, nil, nil).binaryID()}))
+	assert.False(t, q.isStale(raft.ReadState{RequestCtx: q.reads[0].binaryID()}))
+}
+
+func TestClearUnindexedReplicaReads(t *testing.T) {
+	q := &ReadIndexQueue{replica: true}
+	cbs := newTestReplicaReads(q, time.Now(), 3)
+	q.advanceReplicaReads([]raft.ReadState{{Index: 10, RequestCtx: q.reads[1].binaryID()}})
+	q.clearUnindexedReplicaReads(5)
+	assert.Len(t, q.reads, 2)
+	cbs[2].wg.Wait()
+	assert.NotNil(t, cbs[2].resp.Header.Error.StaleCommand)
+	assert.Nil(t, cbs[0].resp)
+	assert.Nil(t, cbs[1].resp)
+}
+
+func TestRetryReplicaReads(t *testing.T) {
+	q := &ReadIndexQueue{replica: true}
+	now := time.Now()
+	oldCbs := newTestReplicaReads(q, now.Add(-time.Minute), 1)
+	newTestReplicaReads(q, now, 2)
+	q.reads[2].readIndex = 10
+
+	var sent [][]byte
+	send := func(ctx []byte) {
+		sent = append(sent, ctx)
+	}
+	q.retryReplicaReads(now.Add(-time.Second), 5, true, send)
+	// The read pending too long is responded, the read without read index is sent again.
+	oldCbs[0].wg.Wait()
+	assert.NotNil(t, oldCbs[0].resp.Header.Error.StaleCommand)
+	assert.Len(t, q.reads, 2)
+	assert.Equal(t, [][]byte{q.reads[0].binaryID()}, sent)
+
+	// The read is resent at most replicaReadMaxRetries times.
+	for i := 0; i < replicaReadMaxRetries; i++ {
+		q.retryReplicaReads(now.Add(-time.Second), 5, true, send)
+	}
+	assert.Len(t, sent, replicaReadMaxRetries)
+	assert.Len(t, q.reads, 2)
+}
+
+func TestRetryReplicaReadsWithoutLeader(t *testing.T) {
+	q := &ReadIndexQueue{replica: true}
+	now := time.Now()
+	cbs := newTestReplicaReads(q, now, 1)
+
+	// The read is not sent without a leader, and it's responded with stale command after the deadline.
+	var sent [][]byte
+	send := func(ctx []byte) {
+		sent = append(sent, ctx)
+	}
+	q.retryReplicaReads(now.Add(-time.Second), 5, false, send)
+	assert.Len(t, sent, 0)
+	assert.Len(t, q.reads, 1)
+	assert.Nil(t, cbs[0].resp)
+	assert.Equal(t, 0, q.reads[0].retries)
+
+	q.retryReplicaReads(now.Add(time.Second), 5, false, send)
+	cbs[0].wg.Wait()
+	assert.NotNil(t, cbs[0].resp.Header.Error.StaleCommand)
+	assert.Len(t, sent, 0)
+	assert.Len(t, q.reads, 0)
+}
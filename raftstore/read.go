@@ -61,6 +61,7 @@ func (c *leaderChecker) IsLeader(ctx *kvrpcpb.Context, router *Router) *errorpb.
 		RegionEpoch: ctx.RegionEpoch,
 		Term:        ctx.Term,
 		SyncLog:     ctx.SyncLog,
+		ReplicaRead: ctx.ReplicaRead,
 	}
 	cmd := &raft_cmdpb.RaftCmdRequest{
 		Header:   header,
@@ -114,7 +115,9 @@ func (c *leaderChecker) isExpired(ctx *kvrpcpb.Context, snapTime *time.Time) (bo
 		return false, err
 	}
 
-	if appliedIndexTerm != term {
+	// The lease of a follower is always expired, so a replica read on a follower goes through read index,
+	// while the leader serves it locally like other reads.
+	if appliedIndexTerm != term || lease == nil {
 		return true, nil
 	}
 	return lease.Inspect(snapTime) == LeaseStateExpired, nil
@@ -0,0 +1,65 @@
+// Copyright 2019-present PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package raftstore
+
+import (
+	"testing"
+	"time"
+	"unsafe"
+
+	"github.com/pingcap/kvproto/pkg/kvrpcpb"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestLeaderChecker(lease *Lease, term uint64) *leaderChecker {
+	region := &metapb.Region{Id: 1, RegionEpoch: &metapb.RegionEpoch{Version: 1}}
+	c := &leaderChecker{peerID: 1, region: unsafe.Pointer(region)}
+	c.term.Store(term)
+	c.appliedIndexTerm.Store(term)
+	if lease != nil {
+		c.leaderLease = unsafe.Pointer(lease.MaybeNewRemoteLease(term))
+	}
+	return c
+}
+
+func TestLeaderCheckerReplicaRead(t *testing.T) {
+	ctx := &kvrpcpb.Context{
+		RegionId:    1,
+		Peer:        &metapb.Peer{Id: 1},
+		RegionEpoch: &metapb.RegionEpoch{Version: 1},
+		ReplicaRead: true,
+	}
+	now := time.Now()
+
+	// The leader in lease serves the replica read locally.
+	lease := NewLease(time.Second)
+	lease.Renew(now)
+	c := newTestLeaderChecker(lease, 5)
+	expired, err := c.isExpired(ctx, &now)
+	assert.Nil(t, err)
+	assert.False(t, expired)
+
+	// The follower which was the leader goes through read index.
+	lease.Expire()
+	expired, err = c.isExpired(ctx, &now)
+	assert.Nil(t, err)
+	assert.True(t, expired)
+
+	// The follower which has never been the leader goes through read index.
+	c = newTestLeaderChecker(nil, 0)
+	expired, err = c.isExpired(ctx, &now)
+	assert.Nil(t, err)
+	assert.True(t, expired)
+}
@@ -15,6 +15,7 @@ package raftstore
 
 import (
 	"bytes"
+	"sort"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -51,61 +52,72 @@ type regionCtx struct {
 	leaderChecker LeaderChecker
 }
 
+const latchSlotCount = 256
+
+// latches serializes the commands on the same keys. The keys are striped into slots by hash, and the commands
+// waiting for a key are queued in the slot, the latch is handed over to the first waiter on release, so
+// waiters are woken in order and don't retry.
 type latches struct {
-	slots [256]map[uint64]*sync.WaitGroup
-	locks [256]sync.Mutex
+	slots [latchSlotCount]latchSlot
+}
+
+type latchSlot struct {
+	mu sync.Mutex
+	// hash -> waiters queued for the latch, a present key means the latch is held.
+	queues map[uint64][]chan struct{}
+	// Pad the slot to a cache line to avoid false sharing between slots.
+	_ [48]byte
 }
 
 func newLatches() *latches {
 	l := &latches{}
-	for i := 0; i < 256; i++ {
-		l.slots[i] = map[uint64]*sync.WaitGroup{}
+	for i := range l.slots {
+		l.slots[i].queues = map[uint64][]chan struct{}{}
 	}
 	return l
 }
 
+func (l *latches) slot(hash uint64) *latchSlot {
+	return &l.slots[hash>>56]
+}
+
 func (l *latches) acquire(keyHashes []uint64) (waitCnt int) {
-	wg := new(sync.WaitGroup)
-	wg.Add(1)
 	for _, hash := range keyHashes {
-		waitCnt += l.acquireOne(hash, wg)
+		waitCnt += l.acquireOne(hash)
 	}
 	return
 }
 
-func (l *latches) acquireOne(hash uint64, wg *sync.WaitGroup) (waitCnt int) {
-	slotID := hash >> 56
-	for {
-		m := l.slots[slotID]
-		l.locks[slotID].Lock()
-		w, ok := m[hash]
-		if !ok {
-			m[hash] = wg
-		}
-		l.locks[slotID].Unlock()
-		if ok {
-			w.Wait()
-			waitCnt++
-			continue
-		}
-		return
+func (l *latches) acquireOne(hash uint64) (waitCnt int) {
+	s := l.slot(hash)
+	s.mu.Lock()
+	q, ok := s.queues[hash]
+	if !ok {
+		s.queues[hash] = nil
+		s.mu.Unlock()
+		return 0
 	}
+	ch := make(chan struct{})
+	s.queues[hash] = append(q, ch)
+	s.mu.Unlock()
+	latchWaiters.Inc()
+	<-ch
+	latchWaiters.Dec()
+	return 1
 }
 
 func (l *latches) release(keyHashes []uint64) {
-	var w *sync.WaitGroup
 	for _, hash := range keyHashes {
-		slotID := hash >> 56
-		l.locks[slotID].Lock()
-		m := l.slots[slotID]
-		if w == nil {
-			w = m[hash]
+		s := l.slot(hash)
+		s.mu.Lock()
+		q := s.queues[hash]
+		if len(q) == 0 {
+			delete(s.queues, hash)
+		} else {
+			s.queues[hash] = q[1:]
+			close(q[0])
 		}
-		delete(m, hash)
-		l.locks[slotID].Unlock()
-	}
-	if w != nil {
-		w.Done()
+		s.mu.Unlock()
 	}
 }
 
@@ -425,13 +437,55 @@ func (rm *RaftRegionManager) runEventHandler() {
 
 // SplitRegion implements the RegionManager interface.
 func (rm *RaftRegionManager) SplitRegion(req *kvrpcpb.SplitRegionRequest) *kvrpcpb.SplitRegionResponse {
-	splitKeys := make([][]byte, 0, len(req.SplitKeys))
-	for _, rawKey := range req.SplitKeys {
-		splitKeys = append(splitKeys, codec.EncodeBytes(nil, rawKey))
+	rawKeys := req.SplitKeys
+	if len(rawKeys) == 0 && len(req.SplitKey) > 0 {
+		rawKeys = [][]byte{req.SplitKey}
+	}
+	rm.mu.RLock()
+	ri := rm.regions[req.GetContext().GetRegionId()]
+	rm.mu.RUnlock()
+	if ri == nil {
+		return &kvrpcpb.SplitRegionResponse{RegionError: &errorpb.Error{
+			Message:        "region not found",
+			RegionNotFound: &errorpb.RegionNotFound{RegionId: req.GetContext().GetRegionId()},
+		}}
+	}
+	splitKeys := encodeSplitKeys(ri.meta, rawKeys)
+	if len(splitKeys) == 0 {
+		// All the keys are region boundaries or out of the region, nothing to split.
+		return &kvrpcpb.SplitRegionResponse{}
 	}
 	regions, err := rm.router.SplitRegion(req.GetContext(), splitKeys)
 	if err != nil {
-		return &kvrpcpb.SplitRegionResponse{RegionError: &errorpb.Error{Message: err.Error()}}
+		return &kvrpcpb.SplitRegionResponse{RegionError: err}
 	}
 	return &kvrpcpb.SplitRegionResponse{Regions: regions}
 }
+
+// encodeSplitKeys encodes the raw split keys, sorts them and removes duplicated keys and keys not
+// strictly inside the region.
+func encodeSplitKeys(region *metapb.Region, rawKeys [][]byte) [][]byte {
+	splitKeys := make([][]byte, 0, len(rawKeys))
+	for _, rawKey := range rawKeys {
+		key := codec.EncodeBytes(nil, rawKey)
+		if bytes.Compare(key, region.StartKey) <= 0 {
+			continue
+		}
+		if len(region.EndKey) > 0 && bytes.Compare(key, region.EndKey) >= 0 {
+			continue
+		}
+		splitKeys = append(splitKeys, key)
+	}
+	sort.Slice(splitKeys, func(i, j int) bool {
+		return bytes.Compare(splitKeys[i], splitKeys[j]) < 0
+	})
+	n := 0
+	for i, key := range splitKeys {
+		if i > 0 && bytes.Equal(key, splitKeys[n-1]) {
+			continue
+		}
+		splitKeys[n] = key
+		n++
+	}
+	return splitKeys[:n]
+}
@@ -0,0 +1,80 @@
+// Copyright 2019-present PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package raftstore
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/tidb/util/codec"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncodeSplitKeys(t *testing.T) {
+	region := &metapb.Region{
+		StartKey: codec.EncodeBytes(nil, []byte("b")),
+		EndKey:   codec.EncodeBytes(nil, []byte("f")),
+	}
+	rawKeys := [][]byte{[]byte("d"), []byte("a"), []byte("b"), []byte("c"), []byte("d"), []byte("f"), []byte("g")}
+	expected := [][]byte{
+		codec.EncodeBytes(nil, []byte("c")),
+		codec.EncodeBytes(nil, []byte("d")),
+	}
+	assert.Equal(t, expected, encodeSplitKeys(region, rawKeys))
+
+	// The last region has no end key.
+	region.EndKey = nil
+	assert.Len(t, encodeSplitKeys(region, rawKeys), 4)
+	assert.Len(t, encodeSplitKeys(region, nil), 0)
+}
+
+func TestLatchesHandOverInOrder(t *testing.T) {
+	l := newLatches()
+	hashes := []uint64{1, 1 << 60}
+	assert.Equal(t, 0, l.acquire(hashes))
+
+	var mu sync.Mutex
+	var order []int
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			assert.Equal(t, 1, l.acquire(hashes[:1]))
+			mu.Lock()
+			order = append(order, i)
+			mu.Unlock()
+			l.release(hashes[:1])
+		}(i)
+		// Make sure the waiters are queued in order.
+		for {
+			s := l.slot(hashes[0])
+			s.mu.Lock()
+			n := len(s.queues[hashes[0]])
+			s.mu.Unlock()
+			if n == i+1 {
+				break
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+	l.release(hashes)
+	wg.Wait()
+	assert.Equal(t, []int{0, 1, 2}, order)
+	for i := range l.slots {
+		assert.Len(t, l.slots[i].queues, 0)
+	}
+}
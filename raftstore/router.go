@@ -20,6 +20,7 @@ import (
 
 	"github.com/ngaut/unistore/raftstore/raftlog"
 	"github.com/pingcap/errors"
+	"github.com/pingcap/kvproto/pkg/errorpb"
 	"github.com/pingcap/kvproto/pkg/kvrpcpb"
 	"github.com/pingcap/kvproto/pkg/metapb"
 	"github.com/pingcap/kvproto/pkg/raft_cmdpb"
@@ -115,7 +116,7 @@ func (r *Router) SendCommand(req *raft_cmdpb.RaftCmdRequest, cb *Callback) error
 }
 
 // SplitRegion splits region by the split keys.
-func (r *Router) SplitRegion(ctx *kvrpcpb.Context, keys [][]byte) ([]*metapb.Region, error) {
+func (r *Router) SplitRegion(ctx *kvrpcpb.Context, keys [][]byte) ([]*metapb.Region, *errorpb.Error) {
 	cb := NewCallback()
 	msg := &MsgSplitRegion{
 		RegionEpoch: ctx.RegionEpoch,
@@ -124,9 +125,12 @@ func (r *Router) SplitRegion(ctx *kvrpcpb.Context, keys [][]byte) ([]*metapb.Reg
 	}
 	err := r.router.send(ctx.RegionId, Msg{Type: MsgTypeSplitRegion, Data: msg})
 	if err != nil {
-		return nil, err
+		return nil, ErrToPbError(err)
 	}
 	cb.wg.Wait()
+	if cb.resp.GetHeader().GetError() != nil {
+		return nil, cb.resp.Header.Error
+	}
 	return cb.resp.GetAdminResponse().GetSplits().GetRegions(), nil
 }
 
@@ -770,12 +770,19 @@ func (s *Snap) Apply(opts ApplyOptions) (ApplyResult, error) {
 	if err != nil {
 		return result, err
 	}
-	applier, err := newSnapApplier(s.CFFiles)
+	applier, err := newSnapApplier(s.CFFiles, true)
 	if err != nil {
 		return result, err
 	}
 	defer applier.close()
+	return applyCFFiles(applier, opts)
+}
 
+// applyCFFiles writes the committed values to the table builder, the locks to the lock store and the rollback
+// and op lock records to the WriteBatch. The keys out of the range of the region are rejected.
+func applyCFFiles(applier *snapApplier, opts ApplyOptions) (ApplyResult, error) {
+	var result ApplyResult
+	startKey, endKey := RawStartKey(opts.Region), RawEndKey(opts.Region)
 	for {
 		item, err1 := applier.next()
 		if err1 != nil {
@@ -784,6 +791,9 @@ func (s *Snap) Apply(opts ApplyOptions) (ApplyResult, error) {
 		if item == nil {
 			break
 		}
+		if bytes.Compare(item.key.UserKey, startKey) < 0 || bytes.Compare(item.key.UserKey, endKey) >= 0 {
+			return result, errors.Errorf("key %x is out of the range of region %d", item.key.UserKey, opts.Region.Id)
+		}
 		switch item.applySnapType {
 		case applySnapTypePut:
 			result.HasPut = true
@@ -58,6 +58,14 @@ func (r *snapRunner) handle(t task) {
 }
 
 func (r *snapRunner) send(t sendSnapTask) {
+	// The snapshot was registered for sending when it was handed to the peer, see snapGenPool.finish.
+	callback := t.callback
+	if snapKey, err := SnapKeyFromSnap(t.msg.GetMessage().GetSnapshot()); err == nil {
+		t.callback = func(err error) {
+			r.snapManager.Deregister(snapKey, SnapEntrySending)
+			callback(err)
+		}
+	}
 	if n := atomic.LoadInt64(&r.sendingCount); n > int64(r.config.ConcurrentSendSnapLimit) {
 		log.Warn("too many sending snapshot tasks, drop send snap", zap.Uint64("to", t.storeID), zap.Stringer("snap", t.msg))
 		t.callback(errors.New("too many sending snapshot tasks"))
@@ -79,9 +87,6 @@ func (r *snapRunner) sendSnap(storeID uint64, msg *raft_serverpb.RaftMessage) er
 		return err
 	}
 
-	r.snapManager.Register(snapKey, SnapEntrySending)
-	defer r.snapManager.Deregister(snapKey, SnapEntrySending)
-
 	snap, err := r.snapManager.GetSnapshotForSending(snapKey)
 	if err != nil {
 		return err
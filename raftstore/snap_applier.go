@@ -44,6 +44,8 @@ const (
 // snapApplier iteratos all the CFs and returns the entries to write to badger.
 type snapApplier struct {
 	lockCFData        []byte
+	lockCFFile        *os.File
+	lockCFIterator    *rocksdb.SstFileIterator
 	defaultCFFile     *os.File
 	defaultCFIterator *rocksdb.SstFileIterator
 	writeCFFile       *os.File
@@ -56,10 +58,16 @@ type snapApplier struct {
 	lastCommitTS      uint64
 }
 
-func newSnapApplier(cfs []*CFFile) (*snapApplier, error) {
-	var err error
+// newSnapApplier creates a snapApplier, the lock CF is a plain file in the snapshot and an SST file when
+// lockCFPlain is false.
+func newSnapApplier(cfs []*CFFile, lockCFPlain bool) (_ *snapApplier, err error) {
 	it := new(snapApplier)
-	if cfs[lockCFIdx].Size > 1 {
+	defer func() {
+		if err != nil {
+			it.close()
+		}
+	}()
+	if lockCFPlain && cfs[lockCFIdx].Size > 1 {
 		it.lockCFData, err = ioutil.ReadFile(cfs[lockCFIdx].Path)
 		if err != nil {
 			return nil, errors.WithStack(err)
@@ -69,6 +77,20 @@ func newSnapApplier(cfs []*CFFile) (*snapApplier, error) {
 			return nil, errors.WithStack(err)
 		}
 	}
+	if !lockCFPlain && cfs[lockCFIdx].Size > 0 {
+		it.lockCFFile, err = os.Open(cfs[lockCFIdx].Path)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		it.lockCFIterator, err = rocksdb.NewSstFileIterator(it.lockCFFile)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		it.lockCFIterator.SeekToFirst()
+		if err = it.readLockFromSST(); err != nil {
+			return nil, err
+		}
+	}
 	if cfs[defaultCFIdx].Size > 0 {
 		it.defaultCFFile, err = os.Open(cfs[defaultCFIdx].Path)
 		if err != nil {
@@ -140,6 +162,10 @@ func (ai *snapApplier) nextLock() (*applySnapItem, error) {
 	mvccLock.Primary = lv.primary
 	mvccLock.Value = val
 	item.val = mvccLock.MarshalBinary()
+	if ai.lockCFIterator != nil {
+		ai.lockCFIterator.Next()
+		return item, ai.readLockFromSST()
+	}
 	if len(ai.lockCFData) > 1 {
 		ai.curLockKey, ai.curLockValue, ai.lockCFData, err = readEntryFromPlainFile(ai.lockCFData)
 		if err != nil {
@@ -151,6 +177,24 @@ func (ai *snapApplier) nextLock() (*applySnapItem, error) {
 	return item, err
 }
 
+func (ai *snapApplier) readLockFromSST() error {
+	if !ai.lockCFIterator.Valid() {
+		ai.curLockKey = nil
+		return ai.lockCFIterator.Err()
+	}
+	userKey := ai.lockCFIterator.Key().UserKey
+	if len(userKey) == 0 || userKey[0] != rocksDBSSTKeyDataPrefix {
+		return errors.WithStack(errBadKeyPrefix)
+	}
+	_, key, err := codec.DecodeBytes(userKey[1:], nil)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	ai.curLockKey = key
+	ai.curLockValue = y.SafeCopy(nil, ai.lockCFIterator.Value())
+	return nil
+}
+
 func (ai *snapApplier) popFullValue(key []byte, startTS uint64, shortVal []byte, op byte) ([]byte, error) {
 	return ai.loadFullValueOpt(key, startTS, shortVal, op, true)
 }
@@ -187,13 +231,13 @@ func (ai *snapApplier) nextWrite() (*applySnapItem, error) {
 		item.applySnapType = applySnapTypeRollback
 		item.key = y.KeyWithTs(ai.curWriteKey, writeVal.startTS)
 		item.userMeta = mvcc.NewDBUserMeta(writeVal.startTS, 0)
-		return item, nil
+		return item, ai.writeCFIteratorNext()
 	}
 	if writeVal.writeType == byte(kvrpcpb.Op_Lock) {
 		item.applySnapType = applySnapTypeOpLock
 		item.key = y.KeyWithTs(ai.curWriteKey, writeVal.startTS)
 		item.userMeta = mvcc.NewDBUserMeta(writeVal.startTS, ai.curWriteCommitTS)
-		return item, nil
+		return item, ai.writeCFIteratorNext()
 	}
 	item.applySnapType = applySnapTypePut
 	item.key = y.KeyWithTs(ai.curWriteKey, ai.curWriteCommitTS)
@@ -220,6 +264,11 @@ func (ai *snapApplier) writeCFIteratorNext() error {
 }
 
 func (ai *snapApplier) close() {
+	if ai.lockCFFile != nil {
+		if err := ai.lockCFFile.Close(); err != nil {
+			log.S().Error(err)
+		}
+	}
 	if ai.writeCFFile != nil {
 		if err := ai.writeCFFile.Close(); err != nil {
 			log.S().Error(err)
@@ -240,7 +289,10 @@ func readEntryFromPlainFile(data []byte) (key, value, remain []byte, err error)
 	if len(key) == 0 {
 		return
 	}
-	key = key[1:]
+	_, key, err = codec.DecodeBytes(key[1:], nil)
+	if err != nil {
+		return
+	}
 	data, value, err = codec.DecodeCompactBytes(data)
 	if err != nil {
 		return
@@ -55,11 +55,13 @@ func newSnapBuilder(cfFiles []*CFFile, snap *regionSnapshot, region *metapb.Regi
 	if b.lockIterator.Valid() && !b.reachEnd(b.lockIterator.Key()) {
 		b.curLockKey = b.lockIterator.Key()
 	}
-	lockCFFile := cfFiles[lockCFIdx].File
-	if lockCFFile == nil {
+	if cfFiles[lockCFIdx].SstWriter != nil {
+		b.lockCFWriter = cfFiles[lockCFIdx].SstWriter
+	} else if cfFiles[lockCFIdx].File != nil {
+		b.lockCFWriter = &plainCFWriter{file: cfFiles[lockCFIdx].File}
+	} else {
 		return nil, errors.New("lock CF file is nil")
 	}
-	b.lockCFWriter = cfFiles[lockCFIdx].File
 	if cfFiles[defaultCFIdx].SstWriter == nil {
 		return nil, errors.New("default CF SstWriter is nil")
 	}
@@ -83,16 +85,34 @@ type snapBuilder struct {
 	curLockKey      []byte
 	curDBKey        []byte
 	curExtraKey     []byte
-	lockCFWriter    *os.File
+	lockCFWriter    cfWriter
 	defaultCFWriter *rocksdb.SstFileWriter
 	writeCFWriter   *rocksdb.SstFileWriter
 	cfFiles         []*CFFile
 	buf             []byte
-	buf2            []byte
 	kvCount         int
 	size            int
 }
 
+// cfWriter writes the key value pairs of a CF in order.
+type cfWriter interface {
+	Put(key, value []byte) error
+}
+
+// plainCFWriter writes the key value pairs as compact bytes into a plain file, it is used by the lock CF of
+// the snapshot.
+type plainCFWriter struct {
+	file *os.File
+	buf  []byte
+}
+
+func (w *plainCFWriter) Put(key, value []byte) error {
+	w.buf = codec.EncodeCompactBytes(w.buf[:0], key)
+	w.buf = codec.EncodeCompactBytes(w.buf, value)
+	_, err := w.file.Write(w.buf)
+	return err
+}
+
 func (b *snapBuilder) build() error {
 	defer func() {
 		b.dbIterator.Close()
@@ -132,10 +152,10 @@ const (
 
 func (b *snapBuilder) currentKeyType() (keyType int) {
 	curKey := b.curDBKey
-	if len(curKey) == 0 || (len(b.curLockKey) > 0 && bytes.Compare(b.curLockKey, curKey) < 0) {
-		keyType = currentKeyLock
+	if len(b.curLockKey) > 0 && (len(curKey) == 0 || bytes.Compare(b.curLockKey, curKey) <= 0) {
+		keyType, curKey = currentKeyLock, b.curLockKey
 	}
-	if len(curKey) == 0 || (len(b.curExtraKey) > 0 && bytes.Compare(b.curExtraKey, curKey) < 0) {
+	if len(b.curExtraKey) > 0 && (len(curKey) == 0 || bytes.Compare(b.curExtraKey, curKey) < 0) {
 		keyType = currentKeyExtra
 	}
 	return
@@ -169,20 +189,13 @@ func (b *snapBuilder) addLockEntry() error {
 		b.size += len(defaultCFKey) + len(l.Value)
 		b.kvCount++
 	}
-	b.buf = codec.EncodeCompactBytes(b.buf[:0], lockCFKey)
-	_, err := b.lockCFWriter.Write(b.buf)
-	if err != nil {
-		return err
-	}
-	b.size += len(b.buf)
-	b.buf2 = encodeLockCFValue(lockCFVal, b.buf2[:0])
-	b.buf = codec.EncodeCompactBytes(b.buf[:0], b.buf2)
-	_, err = b.lockCFWriter.Write(b.buf)
+	b.buf = encodeLockCFValue(lockCFVal, b.buf[:0])
+	err := b.lockCFWriter.Put(lockCFKey, b.buf)
 	if err != nil {
 		return err
 	}
 	b.cfFiles[lockCFIdx].KVCount++
-	b.size += len(b.buf)
+	b.size += len(lockCFKey) + len(b.buf)
 	b.kvCount++
 
 	b.lockIterator.Next()
@@ -39,7 +39,7 @@ type writeCFValue struct {
 const rocksDBSSTKeyDataPrefix = 'z'
 
 func decodeRocksDBSSTKey(k []byte) (key []byte, ts uint64, err error) {
-	if k[0] != rocksDBSSTKeyDataPrefix {
+	if len(k) < 9 || k[0] != rocksDBSSTKeyDataPrefix {
 		return nil, 0, errors.WithStack(errBadKeyPrefix)
 	}
 	encodedKey := k[1 : len(k)-8]
@@ -0,0 +1,168 @@
+// Copyright 2019-present PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package raftstore
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pingcap/kvproto/pkg/eraftpb"
+	"github.com/pingcap/log"
+	"go.uber.org/zap"
+)
+
+// snapGenPool generates region snapshots out of the region worker, so building the CF files doesn't
+// block applying snapshots. All snapshots are written to the snap directory, the concurrency limit bounds
+// the number of generations writing to that disk at the same time.
+type snapGenPool struct {
+	ctx     *snapContext
+	limiter chan struct{}
+	wg      sync.WaitGroup
+
+	mu sync.Mutex
+	// regionID -> notifiers waiting for the in-flight generation.
+	inflight map[uint64][]chan<- *eraftpb.Snapshot
+	// regionID -> the last snapshot generated for the region.
+	cache         map[uint64]*cachedSnap
+	cacheCapacity int
+}
+
+// snapGenCacheCapacity bounds the number of cached snapshots, the least recently generated one is evicted
+// when it's exceeded.
+const snapGenCacheCapacity = 128
+
+type cachedSnap struct {
+	snap    *eraftpb.Snapshot
+	genTime time.Time
+}
+
+func newSnapGenPool(ctx *snapContext, concurrency uint64) *snapGenPool {
+	if concurrency == 0 {
+		concurrency = 1
+	}
+	return &snapGenPool{
+		ctx:           ctx,
+		limiter:       make(chan struct{}, concurrency),
+		inflight:      make(map[uint64][]chan<- *eraftpb.Snapshot),
+		cache:         make(map[uint64]*cachedSnap),
+		cacheCapacity: snapGenCacheCapacity,
+	}
+}
+
+// submit schedules a generation of the region snapshot. If a generation of the same region is running,
+// the notifier waits for its result instead of starting a new one.
+func (p *snapGenPool) submit(regionID, redoIdx uint64, notifier chan<- *eraftpb.Snapshot) {
+	p.mu.Lock()
+	if waiters, ok := p.inflight[regionID]; ok {
+		p.inflight[regionID] = append(waiters, notifier)
+		p.mu.Unlock()
+		log.Info("snapshot generation is in progress, wait for it", zap.Uint64("region id", regionID))
+		return
+	}
+	p.inflight[regionID] = []chan<- *eraftpb.Snapshot{notifier}
+	var cached *eraftpb.Snapshot
+	if c, ok := p.cache[regionID]; ok {
+		cached = c.snap
+	}
+	p.mu.Unlock()
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		p.limiter <- struct{}{}
+		snap := p.reuse(regionID, cached)
+		reused := snap != nil
+		if !reused {
+			var err error
+			snap, err = doSnapshot(p.ctx.engiens, p.ctx.mgr, regionID, redoIdx)
+			if err != nil {
+				log.Error("failed to generate snapshot!!!", zap.Uint64("region id", regionID), zap.Error(err))
+			}
+		}
+		<-p.limiter
+		p.finish(regionID, snap)
+		if reused {
+			p.ctx.mgr.Deregister(SnapKeyFromRegionSnap(regionID, snap), SnapEntrySending)
+		}
+	}()
+}
+
+// reuse returns the cached snapshot if the region has not applied anything since it was generated and
+// its files are still there. The returned snapshot is registered for sending, the caller deregisters it
+// after handing it to the waiters.
+func (p *snapGenPool) reuse(regionID uint64, cached *eraftpb.Snapshot) *eraftpb.Snapshot {
+	if cached == nil {
+		return nil
+	}
+	txn := p.ctx.engiens.kv.DB.NewTransaction(false)
+	defer txn.Discard()
+	index, term, err := getAppliedIdxTermForSnapshot(p.ctx.engiens.raft, txn, regionID)
+	if err != nil {
+		return nil
+	}
+	key := SnapKey{RegionID: regionID, Index: index, Term: term}
+	if cached.Metadata.Index != index || cached.Metadata.Term != term || !p.ctx.mgr.registerSnapshotForSending(key) {
+		return nil
+	}
+	log.Info("reuse generated snapshot", zap.Uint64("region id", regionID), zap.Stringer("snap key", key))
+	return cached
+}
+
+// finish hands the snapshot to all the waiters of the generation, each of them gets its own registration
+// for sending which is dropped when the snapshot is sent, see snapRunner.send. If the generation failed,
+// the waiters get an empty snapshot, so the peers know the generation failed and retry it.
+func (p *snapGenPool) finish(regionID uint64, snap *eraftpb.Snapshot) {
+	p.mu.Lock()
+	waiters := p.inflight[regionID]
+	delete(p.inflight, regionID)
+	if snap != nil {
+		p.cache[regionID] = &cachedSnap{snap: snap, genTime: time.Now()}
+		p.evict()
+	} else {
+		delete(p.cache, regionID)
+	}
+	p.mu.Unlock()
+	for _, notifier := range waiters {
+		if snap == nil {
+			notifier <- &eraftpb.Snapshot{}
+			continue
+		}
+		p.ctx.mgr.Register(SnapKeyFromRegionSnap(regionID, snap), SnapEntrySending)
+		notifier <- snap
+	}
+}
+
+func (p *snapGenPool) evict() {
+	for len(p.cache) > p.cacheCapacity {
+		var oldestID uint64
+		var oldest *cachedSnap
+		for regionID, c := range p.cache {
+			if oldest == nil || c.genTime.Before(oldest.genTime) {
+				oldestID, oldest = regionID, c
+			}
+		}
+		delete(p.cache, oldestID)
+	}
+}
+
+// invalidate drops the cached snapshot of the region, it's called when the region data is replaced or destroyed.
+func (p *snapGenPool) invalidate(regionID uint64) {
+	p.mu.Lock()
+	delete(p.cache, regionID)
+	p.mu.Unlock()
+}
+
+func (p *snapGenPool) stop() {
+	p.wg.Wait()
+}
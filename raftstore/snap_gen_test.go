@@ -0,0 +1,166 @@
+// Copyright 2019-present PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package raftstore
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/pingcap/kvproto/pkg/eraftpb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestSnapGenPool(t *testing.T, concurrency uint64) (*snapGenPool, func()) {
+	kvPath, err := ioutil.TempDir("", "snap_gen_kv")
+	require.Nil(t, err)
+	engines := newEnginesWithKVDb(t, getTestDBForRegions(t, kvPath, []uint64{1, 2}))
+	engines.kvPath = kvPath
+	snapPath, err := ioutil.TempDir("", "snap_gen_snap")
+	require.Nil(t, err)
+	ctx := &snapContext{engiens: engines, mgr: NewSnapManager(snapPath, nil)}
+	return newSnapGenPool(ctx, concurrency), func() {
+		engines.kv.DB.Close()
+		engines.raft.Close()
+		cleanUpTestEngineData(engines)
+		os.RemoveAll(snapPath)
+	}
+}
+
+// snapGenTestRedoIdx is the redo index of the test regions, whose applied index is 10.
+const snapGenTestRedoIdx = 11
+
+func genSnapWithPool(t *testing.T, p *snapGenPool, regionID uint64) *eraftpb.Snapshot {
+	notifier := make(chan *eraftpb.Snapshot, 1)
+	p.submit(regionID, snapGenTestRedoIdx, notifier)
+	select {
+	case snap := <-notifier:
+		return snap
+	case <-time.After(10 * time.Second):
+		require.FailNow(t, "snapshot generation timeout")
+	}
+	return nil
+}
+
+func TestSnapGenPoolDedup(t *testing.T) {
+	p, clean := newTestSnapGenPool(t, 1)
+	defer clean()
+
+	// Occupy the only slot, the generations wait for it.
+	p.limiter <- struct{}{}
+	notifiers := []chan *eraftpb.Snapshot{make(chan *eraftpb.Snapshot, 1), make(chan *eraftpb.Snapshot, 1)}
+	for _, notifier := range notifiers {
+		p.submit(1, snapGenTestRedoIdx, notifier)
+	}
+	p.mu.Lock()
+	assert.Len(t, p.inflight[1], 2)
+	p.mu.Unlock()
+	select {
+	case <-notifiers[0]:
+		require.FailNow(t, "generation should wait for the limiter")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	<-p.limiter
+	snap := <-notifiers[0]
+	assert.True(t, snap == <-notifiers[1])
+	p.stop()
+	assert.Len(t, p.inflight, 0)
+
+	// The snapshot is registered for each waiter until it's sent.
+	key := SnapKeyFromRegionSnap(1, snap)
+	p.ctx.mgr.Deregister(key, SnapEntrySending)
+	assert.True(t, p.ctx.mgr.HasRegistered(key))
+	p.ctx.mgr.Deregister(key, SnapEntrySending)
+	assert.False(t, p.ctx.mgr.HasRegistered(key))
+}
+
+func TestSnapGenPoolFailure(t *testing.T) {
+	p, clean := newTestSnapGenPool(t, 1)
+	defer clean()
+
+	// Region 3 doesn't exist, so the generation fails.
+	p.limiter <- struct{}{}
+	notifiers := []chan *eraftpb.Snapshot{make(chan *eraftpb.Snapshot, 1), make(chan *eraftpb.Snapshot, 1)}
+	for _, notifier := range notifiers {
+		p.submit(3, snapGenTestRedoIdx, notifier)
+	}
+	<-p.limiter
+	for _, notifier := range notifiers {
+		select {
+		case snap := <-notifier:
+			assert.Nil(t, snap.GetMetadata())
+		case <-time.After(10 * time.Second):
+			require.FailNow(t, "waiter is not notified")
+		}
+	}
+	p.stop()
+	assert.Len(t, p.inflight, 0)
+	assert.Len(t, p.cache, 0)
+}
+
+func TestSnapGenPoolReuse(t *testing.T) {
+	p, clean := newTestSnapGenPool(t, 2)
+	defer clean()
+
+	snap := genSnapWithPool(t, p, 1)
+	key := SnapKeyFromRegionSnap(1, snap)
+	isIdle := func() bool {
+		idleSnaps, err := p.ctx.mgr.ListIdleSnap()
+		require.Nil(t, err)
+		for _, idleSnap := range idleSnaps {
+			if idleSnap.SnapKey == key {
+				return true
+			}
+		}
+		return false
+	}
+	assert.False(t, isIdle())
+	p.ctx.mgr.Deregister(key, SnapEntrySending)
+	assert.True(t, isIdle())
+
+	// The reused snapshot is registered until it's sent, so GC doesn't collect it.
+	assert.True(t, snap == genSnapWithPool(t, p, 1))
+	assert.False(t, isIdle())
+	p.ctx.mgr.Deregister(key, SnapEntrySending)
+	assert.True(t, isIdle())
+
+	// The snapshot is generated again after it's invalidated.
+	p.invalidate(1)
+	snap2 := genSnapWithPool(t, p, 1)
+	assert.False(t, snap == snap2)
+	assert.Equal(t, key, SnapKeyFromRegionSnap(1, snap2))
+
+	// The snapshot is generated again after its files are deleted.
+	p.ctx.mgr.Deregister(key, SnapEntrySending)
+	s, err := p.ctx.mgr.GetSnapshotForSending(key)
+	require.Nil(t, err)
+	require.True(t, p.ctx.mgr.DeleteSnapshot(key, s, false))
+	assert.False(t, snap2 == genSnapWithPool(t, p, 1))
+}
+
+func TestSnapGenPoolEvict(t *testing.T) {
+	p, clean := newTestSnapGenPool(t, 2)
+	defer clean()
+	p.cacheCapacity = 1
+
+	genSnapWithPool(t, p, 1)
+	snap := genSnapWithPool(t, p, 2)
+	p.mu.Lock()
+	assert.Len(t, p.cache, 1)
+	assert.True(t, snap == p.cache[2].snap)
+	p.mu.Unlock()
+}
@@ -248,6 +248,19 @@ func (sm *SnapManager) GetSnapshotForSending(snapKey SnapKey) (Snapshot, error)
 	return NewSnapForSending(sm.base, snapKey, sm.snapSize, sm)
 }
 
+// registerSnapshotForSending registers the generated snapshot for sending if its files are still on disk,
+// it returns false if they are not. The snapshot stays registered until it's sent, so GC doesn't delete it
+// in the meantime.
+func (sm *SnapManager) registerSnapshotForSending(snapKey SnapKey) bool {
+	sm.Register(snapKey, SnapEntrySending)
+	snap, err := NewSnap(sm.base, snapKey, sm.snapSize, true, false, sm, nil)
+	if err != nil || !snap.Exists() {
+		sm.Deregister(snapKey, SnapEntrySending)
+		return false
+	}
+	return true
+}
+
 // GetSnapshotForReceiving gets the snapshot for receiving with the given snapshot key and data.
 func (sm *SnapManager) GetSnapshotForReceiving(snapKey SnapKey, data []byte) (Snapshot, error) {
 	snapshotData := new(rspb.RaftSnapshotData)
@@ -278,7 +291,8 @@ func (sm *SnapManager) Register(key SnapKey, entry SnapEntry) {
 	entries, ok := sm.registry[key]
 	if ok {
 		for _, e := range entries {
-			if e == entry {
+			// A snapshot can be sent to several peers at the same time.
+			if e == entry && entry != SnapEntrySending {
 				log.S().Warnf("%s is registered more than 1 time", key)
 				return
 			}
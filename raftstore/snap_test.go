@@ -16,10 +16,12 @@ package raftstore
 import (
 	"fmt"
 	"io/ioutil"
+	"math"
 	"os"
 	"testing"
 
 	"github.com/pingcap/badger"
+	"github.com/pingcap/badger/options"
 	"github.com/pingcap/badger/y"
 	"github.com/pingcap/kvproto/pkg/kvrpcpb"
 	"github.com/pingcap/kvproto/pkg/metapb"
@@ -723,3 +725,124 @@ func TestSnapMaxTotalSize(t *testing.T) {
 	}
 }
 */
+
+func TestSnapBuilderCurrentKeyType(t *testing.T) {
+	cases := []struct {
+		dbKey, lockKey, extraKey string
+		keyType                  int
+	}{
+		{"b", "a", "", currentKeyLock},
+		{"a", "b", "", currentKeyDB},
+		// The lock comes before the committed versions of the same key.
+		{"a", "a", "", currentKeyLock},
+		{"b", "", "a", currentKeyExtra},
+		{"b", "c", "a", currentKeyExtra},
+		{"b", "a", "c", currentKeyLock},
+		// The pending locks are kept after the db iterator is exhausted.
+		{"", "a", "", currentKeyLock},
+		{"", "a", "b", currentKeyLock},
+		{"", "b", "a", currentKeyExtra},
+		{"", "", "a", currentKeyExtra},
+	}
+	for _, c := range cases {
+		b := &snapBuilder{}
+		if c.dbKey != "" {
+			b.curDBKey = []byte(c.dbKey)
+		}
+		if c.lockKey != "" {
+			b.curLockKey = []byte(c.lockKey)
+		}
+		if c.extraKey != "" {
+			b.curExtraKey = []byte(c.extraKey)
+		}
+		assert.Equal(t, c.keyType, b.currentKeyType(), "%+v", c)
+	}
+}
+
+func TestReadEntryFromPlainFile(t *testing.T) {
+	f, err := ioutil.TempFile("", "plain_cf")
+	require.Nil(t, err)
+	defer os.Remove(f.Name())
+	keys := [][]byte{[]byte("tkey"), []byte("tlonger_than_eight_bytes")}
+	w := &plainCFWriter{file: f}
+	for i, key := range keys {
+		require.Nil(t, w.Put(encodeRocksDBSSTKey(key, nil), []byte{byte(i)}))
+	}
+	require.Nil(t, f.Close())
+
+	data, err := ioutil.ReadFile(f.Name())
+	require.Nil(t, err)
+	for i, expected := range keys {
+		// The key is decoded to be compared with the keys of the write CF.
+		var key, value []byte
+		key, value, data, err = readEntryFromPlainFile(data)
+		require.Nil(t, err)
+		assert.Equal(t, expected, key)
+		assert.Equal(t, []byte{byte(i)}, value)
+	}
+	assert.Len(t, data, 0)
+}
+
+func TestSnapApplyKeepsOldVersions(t *testing.T) {
+	region := genTestRegion(1, 1, 1)
+	srcDir, err := ioutil.TempDir("", "snap_apply_src")
+	require.Nil(t, err)
+	defer os.RemoveAll(srcDir)
+	src := openDBBundle(t, srcDir)
+	defer src.DB.Close()
+	fillDBBundleData(t, src)
+
+	snapDir, err := ioutil.TempDir("", "snap_apply_snap")
+	require.Nil(t, err)
+	defer os.RemoveAll(snapDir)
+	mgr := NewSnapManager(snapDir, nil)
+	key := SnapKey{RegionID: region.Id, Term: 1, Index: 10}
+	s, err := mgr.GetSnapshotForBuilding(key)
+	require.Nil(t, err)
+	txn := src.DB.NewTransaction(false)
+	txn.SetReadTS(math.MaxUint64)
+	defer txn.Discard()
+	dbSnap := &regionSnapshot{
+		regionState: &rspb.RegionLocalState{Region: region},
+		txn:         txn,
+		lockSnap:    src.LockStore,
+		index:       key.Index,
+	}
+	snapData := &rspb.RaftSnapshotData{Region: region}
+	require.Nil(t, s.Build(dbSnap, region, snapData, new(SnapStatistics), mgr))
+	data, err := snapData.Marshal()
+	require.Nil(t, err)
+	sending, err := mgr.GetSnapshotForSending(key)
+	require.Nil(t, err)
+	receiving, err := mgr.GetSnapshotForReceiving(key, data)
+	require.Nil(t, err)
+	require.Nil(t, copySnapshot(receiving, sending))
+
+	dstDir, err := ioutil.TempDir("", "snap_apply_dst")
+	require.Nil(t, err)
+	defer os.RemoveAll(dstDir)
+	dst := openDBBundle(t, dstDir)
+	defer dst.DB.Close()
+	s, err = mgr.GetSnapshotForApplying(key)
+	require.Nil(t, err)
+	file, err := ioutil.TempFile(dstDir, "ingest_convert_*.sst")
+	require.Nil(t, err)
+	builder := newIngestTableBuilder(file, options.None, nil)
+	abort := uint32(JobStatusRunning)
+	result, err := s.Apply(ApplyOptions{DBBundle: dst, Region: region, Abort: &abort, Builder: builder, WB: new(WriteBatch)})
+	require.Nil(t, err)
+	require.True(t, result.HasPut)
+	_, err = builder.Finish()
+	require.Nil(t, err)
+	_, err = dst.DB.IngestExternalFiles([]badger.ExternalTableSpec{{Filename: file.Name()}})
+	require.Nil(t, err)
+
+	for _, c := range []struct{ readTS, commitTS uint64 }{{math.MaxUint64, 200}, {150, 100}} {
+		readTxn := dst.DB.NewTransaction(false)
+		readTxn.SetReadTS(c.readTS)
+		item, err := readTxn.Get(snapTestKey)
+		require.Nil(t, err)
+		assert.Equal(t, c.commitTS, mvcc.DBUserMeta(item.UserMeta()).CommitTS())
+		readTxn.Discard()
+	}
+}
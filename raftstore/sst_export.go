@@ -0,0 +1,117 @@
+// Copyright 2019-present PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package raftstore
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/ngaut/unistore/rocksdb"
+	"github.com/pingcap/errors"
+	rspb "github.com/pingcap/kvproto/pkg/raft_serverpb"
+	"github.com/pingcap/log"
+)
+
+// ExportedSST is a RocksDB block-based SST file of a CF exported from the kv engine.
+type ExportedSST struct {
+	CF      CFName
+	Path    string
+	KVCount int
+	Size    uint64
+}
+
+// ExportRegionSSTs writes the data of the region at its applied index into RocksDB block-based SST files in dir,
+// one file per CF in TiKV's format, so the files can be ingested by TiKV or used as BR artifacts. Unlike the
+// snapshot, the lock CF is written as an SST too. The CFs without data don't produce a file.
+func (en *Engines) ExportRegionSSTs(regionID, redoIdx uint64, dir string) (index uint64, ssts []*ExportedSST, err error) {
+	snap, err := en.newRegionSnapshot(regionID, redoIdx)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer snap.txn.Discard()
+	if snap.regionState.GetState() != rspb.PeerState_Normal {
+		return 0, nil, storageError(fmt.Sprintf("region %d is not normal, skip exporting", regionID))
+	}
+	ssts, err = exportRegionSnapshot(snap, dir)
+	if err != nil {
+		return 0, nil, err
+	}
+	return snap.index, ssts, nil
+}
+
+func exportRegionSnapshot(snap *regionSnapshot, dir string) ([]*ExportedSST, error) {
+	region := snap.regionState.Region
+	cfFiles := make([]*CFFile, 0, len(snapshotCFs))
+	defer func() {
+		for _, cfFile := range cfFiles {
+			if cfFile.SstWriter != nil {
+				cfFile.SstWriter.Close()
+			}
+			os.Remove(cfFile.TmpPath)
+		}
+	}()
+	for _, cf := range snapshotCFs {
+		path := filepath.Join(dir, fmt.Sprintf("%d_%d_%s%s", region.Id, snap.index, cf, sstFileSuffix))
+		cfFile := &CFFile{
+			CF:      cf,
+			Path:    path,
+			TmpPath: path + tmpFileSuffix,
+		}
+		cfFiles = append(cfFiles, cfFile)
+		file, err := os.OpenFile(cfFile.TmpPath, os.O_CREATE|os.O_WRONLY, 0600)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		cfFile.SstWriter = rocksdb.NewSstFileWriter(file, rocksdb.NewDefaultBlockBasedTableOptions(bytes.Compare))
+	}
+	builder, err := newSnapBuilder(cfFiles, snap, region)
+	if err != nil {
+		return nil, err
+	}
+	err = builder.build()
+	if err != nil {
+		return nil, err
+	}
+	ssts := make([]*ExportedSST, 0, len(cfFiles))
+	for _, cfFile := range cfFiles {
+		if cfFile.KVCount == 0 {
+			continue
+		}
+		if err = cfFile.SstWriter.Finish(); err != nil {
+			return nil, err
+		}
+		err = cfFile.SstWriter.Close()
+		cfFile.SstWriter = nil
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		fi, err := os.Stat(cfFile.TmpPath)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		if err = os.Rename(cfFile.TmpPath, cfFile.Path); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		ssts = append(ssts, &ExportedSST{
+			CF:      cfFile.CF,
+			Path:    cfFile.Path,
+			KVCount: cfFile.KVCount,
+			Size:    uint64(fi.Size()),
+		})
+	}
+	log.S().Infof("region %d export SSTs at index %d, key count %d, size %d", region.Id, snap.index, builder.kvCount, builder.size)
+	return ssts, nil
+}
@@ -0,0 +1,88 @@
+// Copyright 2019-present PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package raftstore
+
+import (
+	"io/ioutil"
+	"math"
+	"os"
+	"testing"
+
+	"github.com/ngaut/unistore/rocksdb"
+	"github.com/pingcap/kvproto/pkg/kvrpcpb"
+	rspb "github.com/pingcap/kvproto/pkg/raft_serverpb"
+	"github.com/pingcap/tidb/store/mockstore/unistore/lockstore"
+	"github.com/pingcap/tidb/util/codec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportRegionSnapshot(t *testing.T) {
+	dir, err := ioutil.TempDir("", "export")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+	dbBundle := openDBBundle(t, dir)
+	defer dbBundle.DB.Close()
+	fillDBBundleData(t, dbBundle)
+
+	txn := dbBundle.DB.NewTransaction(false)
+	txn.SetReadTS(math.MaxUint64)
+	lockSnap := lockstore.NewMemStore(1024)
+	lockSnap.Put(snapTestKey, dbBundle.LockStore.Get(snapTestKey, nil))
+	snap := &regionSnapshot{
+		regionState: &rspb.RegionLocalState{Region: genTestRegion(1, 1, 1)},
+		txn:         txn,
+		lockSnap:    lockSnap,
+		index:       10,
+	}
+	ssts, err := exportRegionSnapshot(snap, dir)
+	require.Nil(t, err)
+	require.Len(t, ssts, 3)
+
+	// The default CF has the long values of the old version and the lock.
+	expected := map[CFName]int{CFDefault: 2, CFLock: 1, CFWrite: 2}
+	for _, sst := range ssts {
+		assert.Equal(t, expected[sst.CF], sst.KVCount, sst.CF)
+		f, err := os.Open(sst.Path)
+		require.Nil(t, err)
+		fi, err := f.Stat()
+		require.Nil(t, err)
+		assert.Equal(t, uint64(fi.Size()), sst.Size)
+		it, err := rocksdb.NewSstFileIterator(f)
+		require.Nil(t, err)
+		var count int
+		for it.SeekToFirst(); it.Valid(); it.Next() {
+			count++
+			switch sst.CF {
+			case CFLock:
+				_, key, err := codec.DecodeBytes(it.Key().UserKey[1:], nil)
+				require.Nil(t, err)
+				assert.Equal(t, snapTestKey, key)
+				lock, err := decodeLockCFValue(it.Value())
+				require.Nil(t, err)
+				assert.Equal(t, uint64(250), lock.startTS)
+			default:
+				key, _, err := decodeRocksDBSSTKey(it.Key().UserKey)
+				require.Nil(t, err)
+				assert.Equal(t, snapTestKey, key)
+			}
+			if sst.CF == CFWrite {
+				assert.Equal(t, byte(kvrpcpb.Op_Put), decodeWriteCFValue(it.Value()).writeType)
+			}
+		}
+		require.Nil(t, it.Err())
+		assert.Equal(t, sst.KVCount, count)
+		f.Close()
+	}
+}
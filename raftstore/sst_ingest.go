@@ -0,0 +1,133 @@
+// Copyright 2019-present PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package raftstore
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/pingcap/badger"
+	"github.com/pingcap/badger/options"
+	"github.com/pingcap/badger/table/sstable"
+	"github.com/pingcap/errors"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	rspb "github.com/pingcap/kvproto/pkg/raft_serverpb"
+	"github.com/pingcap/log"
+)
+
+// IngestSSTs converts the RocksDB SST files of the default, write and lock CFs, as exported by ExportRegionSSTs
+// or generated by TiKV's importer, into a badger table and ingests it into the kv engine, instead of writing
+// the keys one by one. The locks are put into the lock store, the rollback and op lock records are written by a
+// WriteBatch. The region must be a normal region of this store with the same epoch, and all the keys must be in
+// its range. The caller must make sure there are no concurrent writes in the key range of the files. The
+// converted table is written in the directory of the first file, which must be on the same file system as the
+// kv engine.
+func (en *Engines) IngestSSTs(region *metapb.Region, ssts []*ExportedSST, compression options.CompressionType) error {
+	if err := en.checkIngestRegion(region); err != nil {
+		return err
+	}
+	cfFiles := make([]*CFFile, len(snapshotCFs))
+	for i, cf := range snapshotCFs {
+		cfFiles[i] = &CFFile{CF: cf}
+	}
+	for _, sst := range ssts {
+		idx := cfIndex(sst.CF)
+		if idx < 0 {
+			return errors.Errorf("unknown CF %s of SST %s", sst.CF, sst.Path)
+		}
+		cfFiles[idx].Path = sst.Path
+		cfFiles[idx].Size = sst.Size
+	}
+	if len(ssts) == 0 {
+		return nil
+	}
+	applier, err := newSnapApplier(cfFiles, false)
+	if err != nil {
+		return err
+	}
+	defer applier.close()
+
+	file, err := ioutil.TempFile(filepath.Dir(ssts[0].Path), "ingest_convert_*.sst")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer func() {
+		file.Close()
+		os.Remove(file.Name())
+		os.Remove(sstable.IndexFilename(file.Name()))
+	}()
+	builder := newIngestTableBuilder(file, compression, nil)
+	wb := new(WriteBatch)
+	result, err := applyCFFiles(applier, ApplyOptions{DBBundle: en.kv, Region: region, Builder: builder, WB: wb})
+	if err != nil {
+		return err
+	}
+	if result.HasPut {
+		if _, err = builder.Finish(); err != nil {
+			return err
+		}
+		// The ingested table is hard linked into the engine directory, the temporary files are removed at last.
+		if _, err = en.kv.DB.IngestExternalFiles([]badger.ExternalTableSpec{{Filename: file.Name()}}); err != nil {
+			return err
+		}
+	}
+	log.S().Infof("region %d ingested %d SST files, has put %v", region.Id, len(ssts), result.HasPut)
+	return wb.WriteToKV(en.kv)
+}
+
+// checkIngestRegion checks the region is a normal region of this store and has the same epoch.
+func (en *Engines) checkIngestRegion(region *metapb.Region) error {
+	state, err := getRegionLocalState(en.kv.DB, region.Id)
+	if err != nil {
+		return err
+	}
+	if state.State != rspb.PeerState_Normal {
+		return storageError(fmt.Sprintf("region %d is not normal, skip ingesting", region.Id))
+	}
+	epoch, localEpoch := region.GetRegionEpoch(), state.Region.GetRegionEpoch()
+	if epoch.GetVersion() != localEpoch.GetVersion() || epoch.GetConfVer() != localEpoch.GetConfVer() {
+		return &ErrEpochNotMatch{
+			Message: fmt.Sprintf("region %d epoch %s doesn't match the local epoch %s", region.Id, epoch, localEpoch),
+			Regions: []*metapb.Region{state.Region},
+		}
+	}
+	return nil
+}
+
+// newIngestTableBuilder returns a builder of the table to be ingested into the kv engine. The external table
+// builder of badger doesn't reserve the leading byte of the old block, so the offset of the old versions of the
+// first multi-version key is read back as zero and the old versions are lost. The level 0 table builder is used
+// instead, with the options to build the same table as the external one. The builder can't be Reset for the
+// same reason.
+func newIngestTableBuilder(f *os.File, compression options.CompressionType, limiter *IOLimiter) *sstable.Builder {
+	opt := badger.DefaultOptions.TableBuilderOptions
+	opt.CompressionPerLevel = []options.CompressionType{compression}
+	opt.SuRFStartLevel = 1
+	// The bloom filter FPR of level 0 is 2 * LogicalBloomFPR with these options.
+	opt.MaxLevels = 0
+	opt.LevelSizeMultiplier = 2
+	opt.LogicalBloomFPR /= 2
+	return sstable.NewTableBuilder(f, limiter, 0, opt)
+}
+
+func cfIndex(cf CFName) int {
+	for i, c := range snapshotCFs {
+		if c == cf {
+			return i
+		}
+	}
+	return -1
+}
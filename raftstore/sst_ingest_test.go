@@ -0,0 +1,210 @@
+// Copyright 2019-present PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package raftstore
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ngaut/unistore/rocksdb"
+	"github.com/pingcap/badger/options"
+	"github.com/pingcap/badger/y"
+	"github.com/pingcap/errors"
+	"github.com/pingcap/kvproto/pkg/kvrpcpb"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	rspb "github.com/pingcap/kvproto/pkg/raft_serverpb"
+	"github.com/pingcap/tidb/store/mockstore/unistore/tikv/mvcc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIngestSSTs(t *testing.T) {
+	srcDir, err := ioutil.TempDir("", "ingest_src")
+	require.Nil(t, err)
+	defer os.RemoveAll(srcDir)
+	src := openDBBundle(t, srcDir)
+	defer src.DB.Close()
+	fillDBBundleData(t, src)
+
+	txn := src.DB.NewTransaction(false)
+	txn.SetReadTS(math.MaxUint64)
+	snap := &regionSnapshot{
+		regionState: &rspb.RegionLocalState{Region: genTestRegion(1, 1, 1)},
+		txn:         txn,
+		lockSnap:    src.LockStore,
+		index:       10,
+	}
+	ssts, err := exportRegionSnapshot(snap, srcDir)
+	require.Nil(t, err)
+
+	dstDir, err := ioutil.TempDir("", "ingest_dst")
+	require.Nil(t, err)
+	defer os.RemoveAll(dstDir)
+	dst := openDBBundle(t, dstDir)
+	defer dst.DB.Close()
+	putTestRegionState(t, dst, genTestRegion(1, 1, 1))
+	engines := &Engines{kv: dst, kvPath: dstDir}
+	require.Nil(t, engines.IngestSSTs(genTestRegion(1, 1, 1), ssts, options.None))
+	assert.Len(t, dst.DB.Tables(), 1)
+
+	// Both versions of the key are ingested.
+	for _, c := range []struct {
+		readTS, commitTS uint64
+		valLen           int
+	}{{math.MaxUint64, 200, 32}, {150, 100, 128}} {
+		readTxn := dst.DB.NewTransaction(false)
+		readTxn.SetReadTS(c.readTS)
+		item, err := readTxn.Get(snapTestKey)
+		require.Nil(t, err)
+		assert.Equal(t, c.commitTS, mvcc.DBUserMeta(item.UserMeta()).CommitTS())
+		val, err := item.Value()
+		require.Nil(t, err)
+		assert.Len(t, val, c.valLen)
+		readTxn.Discard()
+	}
+
+	lock := mvcc.DecodeLock(dst.LockStore.Get(snapTestKey, nil))
+	assert.Equal(t, uint64(250), lock.StartTS)
+	assert.Len(t, lock.Value, 128)
+}
+
+func putTestRegionState(t *testing.T, db *mvcc.DBBundle, region *metapb.Region) {
+	wb := new(WriteBatch)
+	require.Nil(t, wb.SetMsg(y.KeyWithTs(RegionStateKey(region.Id), KvTS), &rspb.RegionLocalState{Region: region}))
+	require.Nil(t, wb.WriteToKV(db))
+}
+
+func writeTestSST(t *testing.T, dir string, cf CFName, kvs [][2][]byte) *ExportedSST {
+	path := filepath.Join(dir, cf+sstFileSuffix)
+	file, err := os.Create(path)
+	require.Nil(t, err)
+	w := rocksdb.NewSstFileWriter(file, rocksdb.NewDefaultBlockBasedTableOptions(bytes.Compare))
+	for _, kv := range kvs {
+		require.Nil(t, w.Put(kv[0], kv[1]))
+	}
+	require.Nil(t, w.Finish())
+	require.Nil(t, w.Close())
+	fi, err := os.Stat(path)
+	require.Nil(t, err)
+	return &ExportedSST{CF: cf, Path: path, KVCount: len(kvs), Size: uint64(fi.Size())}
+}
+
+func writeCFKey(key []byte, commitTS uint64) []byte {
+	return encodeRocksDBSSTKey(key, &commitTS)
+}
+
+func listIngestTempFiles(t *testing.T, dir string) []string {
+	files, err := filepath.Glob(filepath.Join(dir, "ingest_convert_*"))
+	require.Nil(t, err)
+	return files
+}
+
+func TestIngestSSTsMultiVersion(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ingest_multi_version")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+	putVal := func(startTS uint64, val string) []byte {
+		return encodeWriteCFValue(&writeCFValue{writeType: byte(kvrpcpb.Op_Put), startTS: startTS, shortValue: []byte(val)})
+	}
+	rollback := encodeWriteCFValue(&writeCFValue{writeType: byte(kvrpcpb.Op_Rollback), startTS: 300})
+	key2 := append(snapTestKey, '2')
+
+	dbDir := filepath.Join(dir, "db")
+	db := openDBBundle(t, dbDir)
+	defer db.DB.Close()
+	region := genTestRegion(1, 1, 1)
+	putTestRegionState(t, db, region)
+	engines := &Engines{kv: db, kvPath: dbDir}
+
+	ssts := []*ExportedSST{writeTestSST(t, dir, CFWrite, [][2][]byte{
+		{writeCFKey(snapTestKey, 300), rollback},
+		{writeCFKey(snapTestKey, 200), putVal(150, "new")},
+		{writeCFKey(snapTestKey, 100), putVal(50, "old")},
+		{writeCFKey(key2, 200), putVal(150, "new2")},
+		{writeCFKey(key2, 100), putVal(50, "old2")},
+		{writeCFKey(key2, 60), putVal(40, "older2")},
+	})}
+	require.Nil(t, engines.IngestSSTs(region, ssts, options.None))
+	assert.Len(t, listIngestTempFiles(t, dir), 0)
+	assert.Len(t, listIngestTempFiles(t, dbDir), 0)
+
+	for _, c := range []struct {
+		key    []byte
+		readTS uint64
+		val    string
+	}{
+		{snapTestKey, math.MaxUint64, "new"},
+		{snapTestKey, 150, "old"},
+		{key2, math.MaxUint64, "new2"},
+		{key2, 150, "old2"},
+		{key2, 80, "older2"},
+	} {
+		txn := db.DB.NewTransaction(false)
+		txn.SetReadTS(c.readTS)
+		item, err := txn.Get(c.key)
+		require.Nil(t, err, "%s at %d", c.key, c.readTS)
+		val, err := item.Value()
+		require.Nil(t, err)
+		assert.Equal(t, c.val, string(val))
+		txn.Discard()
+	}
+}
+
+func TestIngestSSTsBadKey(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ingest_bad_key")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+	dbDir := filepath.Join(dir, "db")
+	db := openDBBundle(t, dbDir)
+	defer db.DB.Close()
+	region := genTestRegion(1, 1, 1)
+	putTestRegionState(t, db, region)
+	engines := &Engines{kv: db, kvPath: dbDir}
+
+	for _, cf := range []CFName{CFLock, CFWrite} {
+		ssts := []*ExportedSST{writeTestSST(t, dir, cf, [][2][]byte{{{}, []byte("v")}})}
+		err = engines.IngestSSTs(region, ssts, options.None)
+		assert.Equal(t, errBadKeyPrefix, errors.Cause(err))
+	}
+}
+
+func TestIngestSSTsCheckRegion(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ingest_check_region")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+	dbDir := filepath.Join(dir, "db")
+	db := openDBBundle(t, dbDir)
+	defer db.DB.Close()
+	engines := &Engines{kv: db, kvPath: dbDir}
+	val := encodeWriteCFValue(&writeCFValue{writeType: byte(kvrpcpb.Op_Put), startTS: 50, shortValue: []byte("v")})
+	ssts := []*ExportedSST{writeTestSST(t, dir, CFWrite, [][2][]byte{{writeCFKey(snapTestKey, 100), val}})}
+
+	region := genTestRegion(1, 1, 1)
+	assert.IsType(t, &ErrRegionNotFound{}, engines.IngestSSTs(region, ssts, options.None))
+
+	putTestRegionState(t, db, region)
+	staleRegion := genTestRegion(1, 1, 1)
+	staleRegion.RegionEpoch.Version = 0
+	assert.IsType(t, &ErrEpochNotMatch{}, engines.IngestSSTs(staleRegion, ssts, options.None))
+
+	// The key is out of the region range.
+	ssts = []*ExportedSST{writeTestSST(t, dir, CFWrite, [][2][]byte{{writeCFKey([]byte("u"), 100), val}})}
+	assert.NotNil(t, engines.IngestSSTs(region, ssts, options.None))
+	assert.Len(t, db.DB.Tables(), 0)
+	assert.Len(t, listIngestTempFiles(t, dir), 0)
+}
@@ -496,7 +496,8 @@ func CloneMsg(origin, cloned proto.Message) error {
 	return proto.Unmarshal(data, cloned)
 }
 
-func deleteAllFilesInRange(db *mvcc.DBBundle, startKey, endKey []byte) error {
-	// todo, needs badger to export api to support delete files.
-	return nil
+// deleteAllFilesInRange drops the tables of the kv engine that only contain keys in [startKey, endKey) to
+// reclaim the space quickly, the keys in the tables that are not fully covered still need to be deleted.
+func deleteAllFilesInRange(db *mvcc.DBBundle, startKey, endKey []byte) {
+	db.DB.DeleteFilesInRange(startKey, endKey)
 }
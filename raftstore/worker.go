@@ -184,6 +184,10 @@ type starter interface {
 	start()
 }
 
+type stopper interface {
+	stop()
+}
+
 func (w *worker) start(handler taskHandler) {
 	w.wg.Add(1)
 	go func() {
@@ -194,6 +198,9 @@ func (w *worker) start(handler taskHandler) {
 		for {
 			task := <-w.receiver
 			if task.tp == taskTypeStop {
+				if s, ok := handler.(stopper); ok {
+					s.stop()
+				}
 				return
 			}
 			handler.handle(task)
@@ -610,24 +617,6 @@ type snapContext struct {
 	pendingDeleteRanges *pendingDeleteRanges
 }
 
-// handleGen handles the task of generating snapshot of the Region. It calls `generateSnap` to do the actual work.
-func (snapCtx *snapContext) handleGen(regionID, redoIdx uint64, notifier chan<- *eraftpb.Snapshot) {
-	if err := snapCtx.generateSnap(regionID, redoIdx, notifier); err != nil {
-		log.Error("failed to generate snapshot!!!", zap.Uint64("region id", regionID), zap.Error(err))
-	}
-}
-
-// generateSnap generates the snapshots of the Region
-func (snapCtx *snapContext) generateSnap(regionID, redoIdx uint64, notifier chan<- *eraftpb.Snapshot) error {
-	// do we need to check leader here?
-	snap, err := doSnapshot(snapCtx.engiens, snapCtx.mgr, regionID, redoIdx)
-	if err != nil {
-		return err
-	}
-	notifier <- snap
-	return nil
-}
-
 // cleanUpOriginData clear up the region data before applying snapshot
 func (snapCtx *snapContext) cleanUpOriginData(regionState *rspb.RegionLocalState, status *JobStatus) error {
 	startKey := RawStartKey(regionState.GetRegion())
@@ -741,11 +730,7 @@ func (snapCtx *snapContext) insertPendingDeleteRange(regionID uint64, startKey,
 // cleanUpRange cleans up the data within the range.
 func (snapCtx *snapContext) cleanUpRange(regionID uint64, startKey, endKey []byte, useDeleteFiles bool) {
 	if useDeleteFiles {
-		if err := deleteAllFilesInRange(snapCtx.engiens.kv, startKey, endKey); err != nil {
-			log.Error("failed to delete files in range", zap.Uint64("region id", regionID), zap.String("start key",
-				hex.EncodeToString(startKey)), zap.String("end key", hex.EncodeToString(endKey)), zap.Error(err))
-			return
-		}
+		deleteAllFilesInRange(snapCtx.engiens.kv, startKey, endKey)
 	}
 	if err := deleteRange(snapCtx.engiens.kv, startKey, endKey); err != nil {
 		log.Error("failed to delete data in range", zap.Uint64("region id", regionID), zap.String("start key",
@@ -762,7 +747,8 @@ type regionApplyState struct {
 }
 
 type regionTaskHandler struct {
-	ctx *snapContext
+	ctx     *snapContext
+	genPool *snapGenPool
 	// we may delay some apply tasks if level 0 files to write stall threshold,
 	// pending_applies records all delayed apply task, and will check again later
 	pendingApplies []task
@@ -776,19 +762,21 @@ type regionTaskHandler struct {
 	applyStates []regionApplyState
 }
 
-func newRegionTaskHandler(conf *config.Config, engines *Engines, mgr *SnapManager, batchSize uint64, cleanStalePeerDelay time.Duration) *regionTaskHandler {
-	return &regionTaskHandler{
-		conf: conf,
-		ctx: &snapContext{
-			engiens:             engines,
-			mgr:                 mgr,
-			batchSize:           batchSize,
-			cleanStalePeerDelay: cleanStalePeerDelay,
-			pendingDeleteRanges: &pendingDeleteRanges{
-				ranges: lockstore.NewMemStore(4096),
-			},
+func newRegionTaskHandler(conf *config.Config, engines *Engines, mgr *SnapManager, batchSize uint64, cleanStalePeerDelay time.Duration, genSnapLimit uint64) *regionTaskHandler {
+	ctx := &snapContext{
+		engiens:             engines,
+		mgr:                 mgr,
+		batchSize:           batchSize,
+		cleanStalePeerDelay: cleanStalePeerDelay,
+		pendingDeleteRanges: &pendingDeleteRanges{
+			ranges: lockstore.NewMemStore(4096),
 		},
 	}
+	return &regionTaskHandler{
+		conf:    conf,
+		ctx:     ctx,
+		genPool: newSnapGenPool(ctx, genSnapLimit),
+	}
 }
 
 func (r *regionTaskHandler) tempFile() (*os.File, error) {
@@ -801,13 +789,7 @@ func (r *regionTaskHandler) resetBuilder() error {
 		return err
 	}
 	compressionType := config.ParseCompression(r.conf.Engine.IngestCompression)
-	if r.builder == nil {
-		r.builder = r.ctx.engiens.kv.DB.NewExternalTableBuilder(r.builderFile, compressionType, r.ctx.mgr.limiter)
-		r.builder.SetIsManaged()
-	} else {
-		r.builder.Reset(r.builderFile)
-	}
-
+	r.builder = newIngestTableBuilder(r.builderFile, compressionType, r.ctx.mgr.limiter)
 	return nil
 }
 
@@ -893,6 +875,7 @@ func (r *regionTaskHandler) handlePendingApplies() {
 		}
 
 		task := apply.data.(*regionTask)
+		r.genPool.invalidate(task.regionID)
 		result, err := r.ctx.handleApply(task.regionID, task.status, r.builder)
 		if err != nil {
 			log.S().Error(err)
@@ -913,7 +896,7 @@ func (r *regionTaskHandler) handle(t task) {
 		// It is safe for now to handle generating and applying snapshot concurrently,
 		// but it may not when merge is implemented.
 		regionTask := t.data.(*regionTask)
-		r.ctx.handleGen(regionTask.regionID, regionTask.redoIdx, regionTask.notifier)
+		r.genPool.submit(regionTask.regionID, regionTask.redoIdx, regionTask.notifier)
 	case taskTypeRegionApply:
 		// To make sure applying snapshots in order.
 		r.pendingApplies = append(r.pendingApplies, t)
@@ -921,14 +904,19 @@ func (r *regionTaskHandler) handle(t task) {
 	case taskTypeRegionDestroy:
 		// Try to delay the range deletion because
 		// there might be a coprocessor request related to this range
-		regionTask := t.data.(regionTask)
+		regionTask := t.data.(*regionTask)
+		r.genPool.invalidate(regionTask.regionID)
 		if !r.ctx.insertPendingDeleteRange(regionTask.regionID, regionTask.startKey, regionTask.endKey) {
 			// Use delete files
-			r.ctx.cleanUpRange(regionTask.regionID, regionTask.startKey, regionTask.endKey, false)
+			r.ctx.cleanUpRange(regionTask.regionID, regionTask.startKey, regionTask.endKey, true)
 		}
 	}
 }
 
+func (r *regionTaskHandler) stop() {
+	r.genPool.stop()
+}
+
 type raftLogGcTaskRes uint64
 
 type raftLogGCTaskHandler struct {
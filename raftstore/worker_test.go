@@ -15,6 +15,7 @@ package raftstore
 
 import (
 	"io/ioutil"
+	"math"
 	"os"
 	"sync"
 	"testing"
@@ -22,6 +23,7 @@ import (
 
 	"github.com/ngaut/unistore/config"
 	"github.com/pingcap/badger"
+	"github.com/pingcap/badger/options"
 	"github.com/pingcap/badger/y"
 	"github.com/pingcap/kvproto/pkg/eraftpb"
 	rspb "github.com/pingcap/kvproto/pkg/raft_serverpb"
@@ -160,7 +162,7 @@ func TestPendingApplies(t *testing.T) {
 	mgr := NewSnapManager(snapPath, nil)
 	wg := new(sync.WaitGroup)
 	worker := newWorker("snap-manager", wg)
-	regionRunner := newRegionTaskHandler(&config.DefaultConf, engines, mgr, 0, time.Second*0)
+	regionRunner := newRegionTaskHandler(&config.DefaultConf, engines, mgr, 0, time.Second*0, 1)
 	worker.start(regionRunner)
 	genAndApplySnap := func(regionID uint64) {
 		tx := make(chan *eraftpb.Snapshot, 1)
@@ -391,3 +393,44 @@ func raftLogMustExist(t *testing.T, db *badger.DB, regionID, startIdx, endIdx ui
 		}
 	}
 }
+
+func TestDestroyRegionDeletesFiles(t *testing.T) {
+	srcDir, err := ioutil.TempDir("", "destroy_src")
+	require.Nil(t, err)
+	defer os.RemoveAll(srcDir)
+	src := openDBBundle(t, srcDir)
+	defer src.DB.Close()
+	fillDBBundleData(t, src)
+	txn := src.DB.NewTransaction(false)
+	txn.SetReadTS(math.MaxUint64)
+	ssts, err := exportRegionSnapshot(&regionSnapshot{
+		regionState: &rspb.RegionLocalState{Region: genTestRegion(1, 1, 1)},
+		txn:         txn,
+		lockSnap:    src.LockStore,
+		index:       10,
+	}, srcDir)
+	require.Nil(t, err)
+
+	kvPath, err := ioutil.TempDir("", "destroy_dst")
+	require.Nil(t, err)
+	kv := openDBBundle(t, kvPath)
+	engines := newEnginesWithKVDb(t, kv)
+	engines.kvPath = kvPath
+	defer cleanUpTestEngineData(engines)
+	putTestRegionState(t, kv, genTestRegion(1, 1, 1))
+	require.Nil(t, engines.IngestSSTs(genTestRegion(1, 1, 1), ssts, options.None))
+	require.Len(t, kv.DB.Tables(), 1)
+
+	regionRunner := newRegionTaskHandler(&config.DefaultConf, engines, nil, 0, 0, 1)
+	regionRunner.handle(task{
+		tp:   taskTypeRegionDestroy,
+		data: &regionTask{regionID: 1, startKey: regionTestBegin, endKey: regionTestEnd},
+	})
+	assert.Len(t, kv.DB.Tables(), 0)
+	readTxn := kv.DB.NewTransaction(false)
+	readTxn.SetReadTS(math.MaxUint64)
+	defer readTxn.Discard()
+	_, err = readTxn.Get(snapTestKey)
+	assert.Equal(t, badger.ErrKeyNotFound, err)
+	assert.Nil(t, kv.LockStore.Get(snapTestKey, nil))
+}
@@ -31,8 +31,9 @@ import (
 )
 
 const (
-	propsBlockHandleKey = "rocksdb.properties"
-	bloomBlockHandleKey = "fullfilter.rocksdb.BuiltinBloomFilter"
+	propsBlockHandleKey            = "rocksdb.properties"
+	bloomBlockHandleKey            = "fullfilter.rocksdb.BuiltinBloomFilter"
+	partitionedBloomBlockHandleKey = "partitionedfilter.rocksdb.BuiltinBloomFilter"
 )
 
 // BlockBasedTableBuilder is used in building a block-based table.
@@ -45,6 +46,10 @@ type BlockBasedTableBuilder struct {
 	indexBlockBuilder *indexBlockBuilder
 	filterBuilder     *fullFilterBlockBuilder
 
+	// partitionedIndex and partitionedFilter are set when the index and the filter are partitioned.
+	partitionedIndex  *partitionedIndexBuilder
+	partitionedFilter *partitionedFilterBlockBuilder
+
 	compressBuf []byte
 
 	offset        uint64
@@ -66,16 +71,26 @@ func NewBlockBasedTableBuilder(f *os.File, opts *BlockBasedTableOptions) *BlockB
 		alignment = opts.BlockSize
 	}
 
-	return &BlockBasedTableBuilder{
+	b := &BlockBasedTableBuilder{
 		writer:                  w,
 		comparator:              opts.Comparator,
 		dataBlockBuilder:        newBlockBuilder(opts.BlockRestartInterval),
 		indexBlockBuilder:       newIndexBlockBuilder(opts.IndexBlockRestartInterval),
-		filterBuilder:           newFullFilterBlockBuilder(opts),
 		opts:                    opts,
 		blockSizeDeviationLimit: blockSizeDeviationLimit,
 		alignment:               alignment,
 	}
+	// The filter is disabled if BloomBitsPerKey is not positive.
+	if opts.BloomBitsPerKey > 0 {
+		b.filterBuilder = newFullFilterBlockBuilder(opts)
+	}
+	if opts.IndexType == IndexTypeTwoLevelSearch {
+		b.partitionedIndex = newPartitionedIndexBuilder(opts)
+		if opts.PartitionFilters && b.filterBuilder != nil {
+			b.partitionedFilter = newPartitionedFilterBlockBuilder(b.filterBuilder, opts)
+		}
+	}
+	return b
 }
 
 // Add adds a key-value pair to the BlockBasedTableBuilder.
@@ -93,10 +108,12 @@ func (b *BlockBasedTableBuilder) Add(key, value []byte) error {
 		if err := b.flush(); err != nil {
 			return err
 		}
-		b.indexBlockBuilder.AddIndexEntry(b.lastKey, &b.pendingHandle)
+		b.addIndexEntry()
 	}
 
-	b.filterBuilder.Add(extractUserKey(key))
+	if b.filterBuilder != nil {
+		b.filterBuilder.Add(extractUserKey(key))
+	}
 
 	b.dataBlockBuilder.Add(key, value)
 	b.props.NumEntries++
@@ -120,7 +137,10 @@ func (b *BlockBasedTableBuilder) Finish() error {
 	}
 
 	if b.dataBlockBuilder.Empty() {
-		b.indexBlockBuilder.AddIndexEntry(b.lastKey, &b.pendingHandle)
+		b.addIndexEntry()
+	}
+	if b.partitionedIndex != nil {
+		b.cutPartition()
 	}
 
 	// Write meta blocks and metaindex block with the following order.
@@ -167,6 +187,25 @@ func (b *BlockBasedTableBuilder) Finish() error {
 	return b.writer.Sync()
 }
 
+func (b *BlockBasedTableBuilder) addIndexEntry() {
+	if b.partitionedIndex == nil {
+		b.indexBlockBuilder.AddIndexEntry(b.lastKey, &b.pendingHandle)
+		return
+	}
+	b.partitionedIndex.AddIndexEntry(b.lastKey, &b.pendingHandle)
+	if b.partitionedIndex.ShouldCut() || (b.partitionedFilter != nil && b.partitionedFilter.ShouldCut()) {
+		b.cutPartition()
+	}
+}
+
+// cutPartition cuts the index partition and the filter partition at the last added key, the filter
+// has the keys of all the data blocks in the index partition at this point.
+func (b *BlockBasedTableBuilder) cutPartition() {
+	if b.partitionedIndex.Cut() && b.partitionedFilter != nil {
+		b.partitionedFilter.Cut(b.lastKey)
+	}
+}
+
 func (b *BlockBasedTableBuilder) flush() error {
 	if b.dataBlockBuilder.Empty() {
 		return nil
@@ -183,7 +222,10 @@ func (b *BlockBasedTableBuilder) flush() error {
 }
 
 func (b *BlockBasedTableBuilder) writeFilterBlock(metaIndexBuilder *metaIndexBuilder) error {
-	if b.filterBuilder.Empty() {
+	if b.partitionedFilter != nil {
+		return b.writePartitionedFilterBlock(metaIndexBuilder)
+	}
+	if b.filterBuilder == nil || b.filterBuilder.Empty() {
 		return nil
 	}
 
@@ -198,12 +240,67 @@ func (b *BlockBasedTableBuilder) writeFilterBlock(metaIndexBuilder *metaIndexBui
 	return nil
 }
 
+// writePartitionedFilterBlock writes the filter partitions and the index on them, the metaindex points
+// to the index of the filter partitions.
+func (b *BlockBasedTableBuilder) writePartitionedFilterBlock(metaIndexBuilder *metaIndexBuilder) error {
+	if b.partitionedFilter.Empty() {
+		return nil
+	}
+
+	filterIndexBuilder := newIndexBlockBuilder(b.opts.IndexBlockRestartInterval)
+	for _, p := range b.partitionedFilter.partitions {
+		var handle blockHandle
+		b.props.FilterSize += uint64(len(p.contents))
+		if err := b.writeRawBlock(p.contents, CompressionNone, &handle, false); err != nil {
+			return err
+		}
+		filterIndexBuilder.AddIndexEntry(p.key, &handle)
+	}
+
+	var filterBlockHandle blockHandle
+	contents := filterIndexBuilder.Finish()
+	b.props.FilterSize += uint64(len(contents))
+	if err := b.writeRawBlock(contents, CompressionNone, &filterBlockHandle, false); err != nil {
+		return err
+	}
+	metaIndexBuilder.AddHandle(partitionedBloomBlockHandleKey, &filterBlockHandle)
+
+	return nil
+}
+
 func (b *BlockBasedTableBuilder) writeIndexBlock(indexBlockHandle *blockHandle) error {
+	if b.partitionedIndex != nil {
+		return b.writePartitionedIndexBlock(indexBlockHandle)
+	}
 	contents := b.indexBlockBuilder.Finish()
+	b.props.IndexSize = uint64(len(contents) + blockTrailerSize)
+	return b.writeIndexContents(contents, indexBlockHandle)
+}
+
+// writePartitionedIndexBlock writes the index partitions and the top-level index on them, the footer
+// points to the top-level index.
+func (b *BlockBasedTableBuilder) writePartitionedIndexBlock(indexBlockHandle *blockHandle) error {
+	for _, p := range b.partitionedIndex.partitions {
+		var handle blockHandle
+		b.props.IndexSize += uint64(len(p.contents) + blockTrailerSize)
+		if err := b.writeIndexContents(p.contents, &handle); err != nil {
+			return err
+		}
+		b.indexBlockBuilder.AddIndexEntry(p.key, &handle)
+	}
+
+	contents := b.indexBlockBuilder.Finish()
+	b.props.IndexSize += uint64(len(contents) + blockTrailerSize)
+	b.props.IndexPartitions = uint64(len(b.partitionedIndex.partitions))
+	b.props.TopLevelIndexSize = uint64(len(contents))
+	return b.writeIndexContents(contents, indexBlockHandle)
+}
+
+func (b *BlockBasedTableBuilder) writeIndexContents(contents []byte, handle *blockHandle) error {
 	if b.opts.EnableIndexCompression {
-		return b.writeBlock(contents, indexBlockHandle, false)
+		return b.writeBlock(contents, handle, false)
 	}
-	return b.writeRawBlock(contents, CompressionNone, indexBlockHandle, false)
+	return b.writeRawBlock(contents, CompressionNone, handle, false)
 }
 
 func (b *BlockBasedTableBuilder) writePropsBlock(metaIndexBuilder *metaIndexBuilder) error {
@@ -225,7 +322,14 @@ func (b *BlockBasedTableBuilder) writePropsBlock(metaIndexBuilder *metaIndexBuil
 	propsBuilder.AddUint64(propFixedKeyLength, 0)
 	propsBuilder.AddUint64(propFormatVersion, 2)
 	propsBuilder.AddUint64(propIndexKeyIsUserKey, 0)
+	if p.IndexPartitions != 0 {
+		propsBuilder.AddUint64(propIndexPartitions, p.IndexPartitions)
+		propsBuilder.AddUint64(propTopLevelIndexSize, p.TopLevelIndexSize)
+	}
 	propsBuilder.AddUint64(propIndexSize, p.IndexSize)
+	propsBuilder.AddFixed32(propIndexType, uint32(b.opts.IndexType))
+	propsBuilder.AddBool(propWholeKeyFiltering, b.opts.WholeKeyFiltering)
+	propsBuilder.AddBool(propPrefixFiltering, b.opts.PrefixExtractor != nil)
 	propsBuilder.AddUint64(propNumDataBlocks, p.NumDataBlocks)
 	propsBuilder.AddUint64(propNumEntries, p.NumEntries)
 	propsBuilder.AddUint64(propOldestKeyTime, p.OldestKeyTime)
@@ -249,7 +353,6 @@ func (b *BlockBasedTableBuilder) setupProperties() {
 	p.ColumnFamilyID = math.MaxInt32
 	p.ColumnFamilyName = ""
 	p.FilterPolicyName = "rocksdb.BuiltinBloomFilter"
-	p.IndexSize = uint64(b.indexBlockBuilder.IndexSize() + blockTrailerSize)
 	p.CompressionName = b.opts.CompressionType.String()
 	p.CreationTime = b.opts.CreationTime
 	p.OldestKeyTime = b.opts.OldestKeyTime
@@ -276,17 +379,11 @@ func (b *BlockBasedTableBuilder) writeRawBlock(contents []byte, tp CompressionTy
 
 	var trailer [blockTrailerSize]byte
 	trailer[0] = byte(tp)
-	switch b.opts.ChecksumType {
-	case ChecksumNone:
-		rocksEndian.PutUint32(trailer[1:], 0)
-	case ChecksumCRC32:
-		crc := newCrc32()
-		crc.Write(contents)
-		crc.Write(trailer[0:1])
-		rocksEndian.PutUint32(trailer[1:], maskCrc32(crc.Sum32()))
-	case ChecksumXXHash:
-		panic("unsupported")
+	checksum, err := blockChecksum(b.opts.ChecksumType, contents, trailer[0])
+	if err != nil {
+		return err
 	}
+	rocksEndian.PutUint32(trailer[1:], checksum)
 	if err := b.writer.Append(trailer[:]); err != nil {
 		return err
 	}
@@ -14,12 +14,18 @@
 package rocksdb
 
 type blockIterator struct {
-	data    []byte
-	cursor  int
-	invalid bool
+	data        []byte
+	restarts    []byte
+	numRestarts int
+	cursor      int
+	invalid     bool
 
 	keyBuf   []byte
 	valueBuf []byte
+
+	// savedKey and savedValue keep the previous entry in SeekForPrev.
+	savedKey   []byte
+	savedValue []byte
 }
 
 func newBlockIterator(block []byte) *blockIterator {
@@ -37,6 +43,73 @@ func (it *blockIterator) Rewind() {
 	it.cursor = 0
 }
 
+// Seek moves the iterator to the first entry whose key is not less than target.
+func (it *blockIterator) Seek(target []byte, cmp Comparator) {
+	// Binary search for the last restart point whose key is less than target.
+	left, right := 0, it.numRestarts-1
+	for left < right {
+		mid := (left + right + 1) / 2
+		it.seekToRestartPoint(mid)
+		if it.Valid() && cmp.CompareInternalKey(it.Key(), target) < 0 {
+			left = mid
+		} else {
+			right = mid - 1
+		}
+	}
+	it.seekToRestartPoint(left)
+	for it.Valid() && cmp.CompareInternalKey(it.Key(), target) < 0 {
+		it.Next()
+	}
+}
+
+// SeekForPrev moves the iterator to the last entry whose key is not greater than target.
+func (it *blockIterator) SeekForPrev(target []byte, cmp Comparator) {
+	// Binary search for the last restart point whose key is not greater than target.
+	left, right := 0, it.numRestarts-1
+	for left < right {
+		mid := (left + right + 1) / 2
+		it.seekToRestartPoint(mid)
+		if it.Valid() && cmp.CompareInternalKey(it.Key(), target) <= 0 {
+			left = mid
+		} else {
+			right = mid - 1
+		}
+	}
+	it.seekToRestartPoint(left)
+	if !it.Valid() || cmp.CompareInternalKey(it.Key(), target) > 0 {
+		it.invalid = true
+		return
+	}
+	for !it.end() {
+		cursor := it.cursor
+		it.savedKey = append(it.savedKey[:0], it.keyBuf...)
+		it.valueBuf, it.savedValue = it.savedValue, it.valueBuf
+		it.Next()
+		if !it.Valid() || cmp.CompareInternalKey(it.Key(), target) > 0 {
+			it.cursor = cursor
+			it.invalid = false
+			it.keyBuf, it.savedKey = it.savedKey, it.keyBuf
+			it.valueBuf, it.savedValue = it.savedValue, it.valueBuf
+			return
+		}
+	}
+}
+
+// SeekToLast moves the iterator to the last entry.
+func (it *blockIterator) SeekToLast() {
+	it.seekToRestartPoint(it.numRestarts - 1)
+	for it.Valid() && !it.end() {
+		it.Next()
+	}
+}
+
+func (it *blockIterator) seekToRestartPoint(i int) {
+	it.cursor = int(rocksEndian.Uint32(it.restarts[i*4:]))
+	it.invalid = false
+	it.keyBuf = it.keyBuf[:0]
+	it.Next()
+}
+
 func (it *blockIterator) Next() {
 	if it.end() {
 		it.invalid = true
@@ -89,6 +162,8 @@ func (it *blockIterator) Reset(block []byte) {
 	data := block[:len(block)-restartsSz]
 
 	it.data = data
+	it.restarts = block[len(data) : len(block)-4]
+	it.numRestarts = int(numRestarts)
 	it.cursor = 0
 	it.invalid = false
 	it.keyBuf = it.keyBuf[:0]
@@ -0,0 +1,48 @@
+// Copyright 2019-present PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rocksdb
+
+import (
+	"github.com/cespare/xxhash/v2"
+	"github.com/pingcap/errors"
+)
+
+// xxh3LastBytePrime mixes the last byte into the XXH3 checksum, so the XXH3 checksum doesn't need to hash
+// the block contents and the compression type in a streaming way.
+const xxh3LastBytePrime = 0x6b9083d9
+
+// blockChecksum returns the checksum stored in the block trailer, it covers the block contents and
+// the compression type.
+func blockChecksum(tp ChecksumType, contents []byte, compressionType byte) (uint32, error) {
+	switch tp {
+	case ChecksumNone:
+		return 0, nil
+	case ChecksumCRC32:
+		crc := newCrc32()
+		crc.Write(contents)
+		crc.Write([]byte{compressionType})
+		return maskCrc32(crc.Sum32()), nil
+	case ChecksumXXHash:
+		return xxh32(contents, compressionType), nil
+	case ChecksumXXHash64:
+		d := xxhash.New()
+		_, _ = d.Write(contents)
+		_, _ = d.Write([]byte{compressionType})
+		return uint32(d.Sum64()), nil
+	case ChecksumXXH3:
+		return uint32(xxh3(contents)) ^ uint32(compressionType)*xxh3LastBytePrime, nil
+	default:
+		return 0, errors.Errorf("unsupported checksum type %d", tp)
+	}
+}
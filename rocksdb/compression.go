@@ -25,6 +25,8 @@ package rocksdb
 import (
 	"math"
 
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
 	"github.com/pierrec/lz4"
 	"github.com/pingcap/errors"
 )
@@ -56,6 +58,30 @@ func lz4Compress(input, dst []byte) []byte {
 	return dst[:len(decompressedSize)+n]
 }
 
+func snappyCompress(input, dst []byte) []byte {
+	return snappy.Encode(dst[:cap(dst)], input)
+}
+
+// zstdEncoder and zstdDecoder are shared by all the tables, EncodeAll and DecodeAll are safe for
+// concurrent use.
+var (
+	zstdEncoder, _ = zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1))
+	zstdDecoder, _ = zstd.NewReader(nil, zstd.WithDecoderConcurrency(1))
+)
+
+// zstdCompress compresses input in format_version 2, the decompressed size is prepended as varint32.
+func zstdCompress(input, dst []byte) []byte {
+	rawLen := len(input)
+	if rawLen > math.MaxUint32 {
+		return nil
+	}
+
+	var varintBuf [5]byte
+	decompressedSize := encodeVarint32(varintBuf[:], uint32(rawLen))
+	dst = append(dst[:0], decompressedSize...)
+	return zstdEncoder.EncodeAll(input, dst)
+}
+
 func isGoodCompressionRatio(compressed, input []byte) bool {
 	cl, rl := len(compressed), len(input)
 	return cl < rl-(rl/8)
@@ -72,9 +98,9 @@ func CompressBlock(tp CompressionType, input, dst []byte) ([]byte, bool) {
 	case CompressionNone:
 		return input, false
 	case CompressionSnappy:
-		panic("unsupported")
+		compressed = snappyCompress(input, dst)
 	case CompressionZstd:
-		panic("unsupported")
+		compressed = zstdCompress(input, dst)
 	}
 	if compressed == nil || !isGoodCompressionRatio(compressed, input) {
 		return input, false
@@ -98,6 +124,36 @@ func lz4Decompress(input, dst []byte) ([]byte, error) {
 	return dst, err
 }
 
+func snappyDecompress(input, dst []byte) ([]byte, error) {
+	size, err := snappy.DecodedLen(input)
+	if err != nil {
+		return input, ErrDecompress
+	}
+	if cap(dst) < size {
+		dst = make([]byte, size)
+	}
+	return snappy.Decode(dst[:size], input)
+}
+
+func zstdDecompress(input, dst []byte) ([]byte, error) {
+	size, n := decodeVarint32(input)
+	if n <= 0 {
+		return input, ErrDecompress
+	}
+
+	if uint32(cap(dst)) < size {
+		dst = make([]byte, 0, size)
+	}
+	out, err := zstdDecoder.DecodeAll(input[n:], dst[:0])
+	if err != nil {
+		return input, err
+	}
+	if uint32(len(out)) != size {
+		return input, ErrDecompress
+	}
+	return out, nil
+}
+
 // DecompressBlock decompresses input into dst.  If you have a buffer to use, you can pass it to
 // prevent allocation.  If it is too small, or if nil is passed, a new buffer
 // will be allocated and returned.
@@ -108,9 +164,9 @@ func DecompressBlock(tp CompressionType, input, dst []byte) ([]byte, error) {
 	case CompressionNone:
 		return input, nil
 	case CompressionSnappy:
-		panic("unsupported")
+		return snappyDecompress(input, dst)
 	case CompressionZstd:
-		panic("unsupported")
+		return zstdDecompress(input, dst)
 	default:
 		panic("unreachable branch")
 	}
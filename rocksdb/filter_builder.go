@@ -126,6 +126,7 @@ func (b *fullFilterBitsBuilder) Finish() []byte {
 	}
 	buf[totalBits/8] = byte(b.numProbes)
 	rocksEndian.PutUint32(buf[totalBits/8+1:], numLines)
+	b.hashEntries = b.hashEntries[:0]
 	return buf
 }
 
@@ -0,0 +1,84 @@
+//  Copyright (c) 2011-present, Facebook, Inc.  All rights reserved.
+//  This source code is licensed under both the GPLv2 (found in the
+//  COPYING file in the root directory) and Apache 2.0 License
+//  (found in the LICENSE.Apache file in the root directory).
+//
+// Copyright (c) 2011 The LevelDB Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file. See the AUTHORS file for names of contributors.
+
+// Copyright 2019-present PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rocksdb
+
+// filterReader checks the full filter or the partitioned filter of an SST file.
+type filterReader struct {
+	// full is the full filter, it's nil if the filter is partitioned.
+	full []byte
+
+	partitionIndex  *blockIterator
+	partitionHandle blockHandle
+	partition       []byte
+	cmp             Comparator
+	readBlock       func(handle blockHandle) ([]byte, error)
+}
+
+// MayContain returns false if the user key is definitely not in the SST file.
+func (r *filterReader) MayContain(userKey []byte) (bool, error) {
+	if r.partitionIndex == nil {
+		return bloomMayMatch(r.full, userKey), nil
+	}
+	r.partitionIndex.Seek(seekKey(userKey, maxSeqAndType), r.cmp)
+	if !r.partitionIndex.Valid() {
+		return false, nil
+	}
+	var handle blockHandle
+	handle.Decode(r.partitionIndex.Value())
+	if r.partition == nil || handle != r.partitionHandle {
+		partition, err := r.readBlock(handle)
+		if err != nil {
+			return false, err
+		}
+		r.partitionHandle, r.partition = handle, partition
+	}
+	return bloomMayMatch(r.partition, userKey), nil
+}
+
+// bloomMayMatch checks the key against a filter built by fullFilterBitsBuilder.
+func bloomMayMatch(filter, key []byte) bool {
+	if len(filter) <= 5 {
+		// The filter is empty.
+		return false
+	}
+	numProbes := int8(filter[len(filter)-5])
+	numLines := rocksEndian.Uint32(filter[len(filter)-4:])
+	bitsLen := uint32(len(filter) - 5)
+	if numProbes < 1 || numLines == 0 || bitsLen%numLines != 0 {
+		// The filter is broken or built by the newer implementations, regard it as match.
+		return true
+	}
+	lineBits := bitsLen / numLines * 8
+
+	hash := bloomHash(key)
+	delta := (hash >> 17) | (hash << 15)
+	base := (hash % numLines) * lineBits
+	for i := int8(0); i < numProbes; i++ {
+		bitpos := base + (hash % lineBits)
+		if filter[bitpos/8]&(1<<(bitpos%8)) == 0 {
+			return false
+		}
+		hash += delta
+	}
+	return true
+}
@@ -0,0 +1,42 @@
+// Copyright 2019-present PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rocksdb
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBloomMayMatch(t *testing.T) {
+	opts := NewDefaultBlockBasedTableOptions(bytes.Compare)
+	b := newFullFilterBlockBuilder(opts)
+	require.False(t, bloomMayMatch(b.Finish(), []byte("a")))
+
+	const num = 10000
+	for i := 0; i < num; i++ {
+		b.Add([]byte(fmt.Sprintf("%08d", 2*i)))
+	}
+	filter := b.Finish()
+	var falsePositives int
+	for i := 0; i < num; i++ {
+		require.True(t, bloomMayMatch(filter, []byte(fmt.Sprintf("%08d", 2*i))))
+		if bloomMayMatch(filter, []byte(fmt.Sprintf("%08d", 2*i+1))) {
+			falsePositives++
+		}
+	}
+	require.True(t, falsePositives < num/50, falsePositives)
+}
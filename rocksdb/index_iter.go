@@ -0,0 +1,127 @@
+// Copyright 2019-present PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rocksdb
+
+// indexIterator iterates the data block handles of an SST file. If the index is partitioned, topLevel
+// iterates the index partitions and index iterates the entries of the current partition.
+// All the methods return errEnd if the iterator is not positioned at an entry.
+type indexIterator struct {
+	topLevel  *blockIterator
+	index     *blockIterator
+	cmp       Comparator
+	readBlock func(handle blockHandle) ([]byte, error)
+}
+
+func (it *indexIterator) SeekToFirst() error {
+	if it.topLevel != nil {
+		it.topLevel.SeekToFirst()
+		if err := it.loadPartition(); err != nil {
+			return err
+		}
+	}
+	it.index.SeekToFirst()
+	return it.check()
+}
+
+func (it *indexIterator) Next() error {
+	if !it.index.end() {
+		it.index.Next()
+		return it.check()
+	}
+	if it.topLevel == nil || it.topLevel.end() {
+		return errEnd
+	}
+	it.topLevel.Next()
+	if err := it.loadPartition(); err != nil {
+		return err
+	}
+	it.index.SeekToFirst()
+	return it.check()
+}
+
+// Seek moves to the first data block whose last key is not less than target.
+func (it *indexIterator) Seek(target []byte) error {
+	if it.topLevel != nil {
+		it.topLevel.Seek(target, it.cmp)
+		if err := it.loadPartition(); err != nil {
+			return err
+		}
+	}
+	it.index.Seek(target, it.cmp)
+	return it.check()
+}
+
+// SeekForPrev moves to the last data block whose last key is not greater than target.
+func (it *indexIterator) SeekForPrev(target []byte) error {
+	if it.topLevel == nil {
+		it.index.SeekForPrev(target, it.cmp)
+		return it.check()
+	}
+	// The first partition whose key is not less than target may have entries before target.
+	it.topLevel.Seek(target, it.cmp)
+	if it.topLevel.Valid() {
+		if err := it.loadPartition(); err != nil {
+			return err
+		}
+		it.index.SeekForPrev(target, it.cmp)
+		if it.index.Valid() {
+			return nil
+		}
+	}
+	it.topLevel.SeekForPrev(target, it.cmp)
+	if err := it.loadPartition(); err != nil {
+		return err
+	}
+	it.index.SeekToLast()
+	return it.check()
+}
+
+func (it *indexIterator) SeekToLast() error {
+	if it.topLevel != nil {
+		it.topLevel.SeekToLast()
+		if err := it.loadPartition(); err != nil {
+			return err
+		}
+	}
+	it.index.SeekToLast()
+	return it.check()
+}
+
+// Handle returns the handle of the current data block.
+func (it *indexIterator) Handle() blockHandle {
+	var handle blockHandle
+	handle.Decode(it.index.Value())
+	return handle
+}
+
+func (it *indexIterator) loadPartition() error {
+	if !it.topLevel.Valid() {
+		return errEnd
+	}
+	var handle blockHandle
+	handle.Decode(it.topLevel.Value())
+	partition, err := it.readBlock(handle)
+	if err != nil {
+		return err
+	}
+	it.index.Reset(partition)
+	return nil
+}
+
+func (it *indexIterator) check() error {
+	if !it.index.Valid() {
+		return errEnd
+	}
+	return nil
+}
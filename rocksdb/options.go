@@ -56,9 +56,24 @@ type ChecksumType uint8
 
 // ChecksumType
 const (
-	ChecksumNone   ChecksumType = 0x0
-	ChecksumCRC32  ChecksumType = 0x1
-	ChecksumXXHash ChecksumType = 0x2
+	ChecksumNone     ChecksumType = 0x0
+	ChecksumCRC32    ChecksumType = 0x1
+	ChecksumXXHash   ChecksumType = 0x2
+	ChecksumXXHash64 ChecksumType = 0x3
+	ChecksumXXH3     ChecksumType = 0x4
+)
+
+// IndexType specifies the format of the index.
+type IndexType uint32
+
+// IndexType
+const (
+	// IndexTypeBinarySearch is a single index block that is searched by binary search.
+	IndexTypeBinarySearch IndexType = 0x0
+	// IndexTypeTwoLevelSearch partitions the index into blocks of MetadataBlockSize, and a top-level
+	// index is built on the partitions. If PartitionFilters is set, the filter is partitioned at the
+	// same positions as the index.
+	IndexTypeTwoLevelSearch IndexType = 0x2
 )
 
 // BlockBasedTableOptions represents block-based table options.
@@ -71,6 +86,9 @@ type BlockBasedTableOptions struct {
 	CompressionType           CompressionType
 	ChecksumType              ChecksumType
 	EnableIndexCompression    bool
+	IndexType                 IndexType
+	PartitionFilters          bool
+	MetadataBlockSize         int
 	CreationTime              uint64
 	OldestKeyTime             uint64
 
@@ -100,6 +118,9 @@ func NewDefaultBlockBasedTableOptions(cmp Comparator) *BlockBasedTableOptions {
 		CompressionType:           CompressionNone,
 		ChecksumType:              ChecksumCRC32,
 		EnableIndexCompression:    true,
+		IndexType:                 IndexTypeBinarySearch,
+		PartitionFilters:          false,
+		MetadataBlockSize:         4 * 1024,
 		CreationTime:              0,
 		OldestKeyTime:             0,
 
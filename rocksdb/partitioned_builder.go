@@ -0,0 +1,107 @@
+//  Copyright (c) 2011-present, Facebook, Inc.  All rights reserved.
+//  This source code is licensed under both the GPLv2 (found in the
+//  COPYING file in the root directory) and Apache 2.0 License
+//  (found in the LICENSE.Apache file in the root directory).
+//
+// Copyright (c) 2011 The LevelDB Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file. See the AUTHORS file for names of contributors.
+
+// Copyright 2019-present PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rocksdb
+
+import "github.com/pingcap/badger/y"
+
+type partition struct {
+	key      []byte
+	contents []byte
+}
+
+// partitionedIndexBuilder builds the index partitions of a two-level index, the key of a partition is
+// the last key in it.
+type partitionedIndexBuilder struct {
+	restartInterval int
+	partitionSize   int
+	curr            *indexBlockBuilder
+	lastKey         []byte
+	partitions      []partition
+}
+
+func newPartitionedIndexBuilder(opts *BlockBasedTableOptions) *partitionedIndexBuilder {
+	return &partitionedIndexBuilder{
+		restartInterval: opts.IndexBlockRestartInterval,
+		partitionSize:   opts.MetadataBlockSize,
+		curr:            newIndexBlockBuilder(opts.IndexBlockRestartInterval),
+	}
+}
+
+func (b *partitionedIndexBuilder) AddIndexEntry(lastKey []byte, handle *blockHandle) {
+	b.curr.AddIndexEntry(lastKey, handle)
+	b.lastKey = y.SafeCopy(b.lastKey, lastKey)
+}
+
+func (b *partitionedIndexBuilder) ShouldCut() bool {
+	return b.curr.blockBuilder.EstimateSize() >= b.partitionSize
+}
+
+// Cut finishes the current partition, it returns false if the partition is empty.
+func (b *partitionedIndexBuilder) Cut() bool {
+	if b.curr.blockBuilder.Empty() {
+		return false
+	}
+	b.partitions = append(b.partitions, partition{
+		key:      y.SafeCopy(nil, b.lastKey),
+		contents: b.curr.Finish(),
+	})
+	b.curr = newIndexBlockBuilder(b.restartInterval)
+	return true
+}
+
+// partitionedFilterBlockBuilder builds the full filter partitions, the filter is cut with the index, so
+// a partition covers the same data blocks as the index partition with the same key.
+type partitionedFilterBlockBuilder struct {
+	filter           *fullFilterBlockBuilder
+	keysPerPartition int
+	numAdded         int
+	partitions       []partition
+}
+
+func newPartitionedFilterBlockBuilder(filter *fullFilterBlockBuilder, opts *BlockBasedTableOptions) *partitionedFilterBlockBuilder {
+	keysPerPartition := opts.MetadataBlockSize * 8 / opts.BloomBitsPerKey
+	return &partitionedFilterBlockBuilder{
+		filter:           filter,
+		keysPerPartition: keysPerPartition,
+	}
+}
+
+func (b *partitionedFilterBlockBuilder) ShouldCut() bool {
+	return b.filter.numAdded >= b.keysPerPartition
+}
+
+func (b *partitionedFilterBlockBuilder) Cut(key []byte) {
+	b.numAdded += b.filter.numAdded
+	b.filter.numAdded = 0
+	b.filter.lastWholeKeyRecorded = false
+	b.filter.lastPrefixRecorded = false
+	// An empty partition is still written, so every index partition has a filter partition.
+	b.partitions = append(b.partitions, partition{
+		key:      y.SafeCopy(nil, key),
+		contents: b.filter.bitsBuilder.Finish(),
+	})
+}
+
+func (b *partitionedFilterBlockBuilder) Empty() bool {
+	return b.numAdded == 0 && b.filter.Empty()
+}
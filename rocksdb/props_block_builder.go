@@ -39,13 +39,21 @@ const (
 	propFixedKeyLength      = "rocksdb.fixed.key.length"
 	propFormatVersion       = "rocksdb.format.version"
 	propIndexKeyIsUserKey   = "rocksdb.index.key.is.user.key"
+	propIndexPartitions     = "rocksdb.index.partitions"
 	propIndexSize           = "rocksdb.index.size"
+	propIndexType           = "rocksdb.block.based.table.index.type"
 	propNumDataBlocks       = "rocksdb.num.data.blocks"
 	propNumEntries          = "rocksdb.num.entries"
 	propOldestKeyTime       = "rocksdb.oldest.key.time"
 	propPrefixExtractorName = "rocksdb.prefix.extractor.name"
 	propRawKeySize          = "rocksdb.raw.key.size"
 	propRawValueSize        = "rocksdb.raw.value.size"
+	propTopLevelIndexSize   = "rocksdb.top-level.index.size"
+	propWholeKeyFiltering   = "rocksdb.block.based.table.whole.key.filtering"
+	propPrefixFiltering     = "rocksdb.block.based.table.prefix.filtering"
+
+	propTrue  = "1"
+	propFalse = "0"
 )
 
 // PropsInjector is a function of properties injector.
@@ -79,6 +87,22 @@ func (b *PropsBlockBuilder) AddUint64(name string, value uint64) {
 	b.Add(name, encodeVarint64(buf[:], value))
 }
 
+// AddFixed32 adds an uint32 value with the given name, it's encoded in fixed 4 bytes.
+func (b *PropsBlockBuilder) AddFixed32(name string, value uint32) {
+	var buf [4]byte
+	rocksEndian.PutUint32(buf[:], value)
+	b.Add(name, buf[:])
+}
+
+// AddBool adds a bool value with the given name.
+func (b *PropsBlockBuilder) AddBool(name string, value bool) {
+	if value {
+		b.AddString(name, propTrue)
+	} else {
+		b.AddString(name, propFalse)
+	}
+}
+
 // AddString adds an string value with the given name.
 func (b *PropsBlockBuilder) AddString(name, value string) {
 	b.Add(name, []byte(value))
@@ -14,6 +14,7 @@
 package rocksdb
 
 import (
+	"bytes"
 	"os"
 
 	"github.com/pingcap/errors"
@@ -28,21 +29,24 @@ var (
 
 // SstFileIterator is an iterator for an SST file.
 type SstFileIterator struct {
-	f              *os.File
-	indexBlockIter *blockIterator
-	dataBlockIter  *blockIterator
-	readBuf        []byte
-	dataBuf        []byte
-	invalid        bool
-	err            error
-	checksumType   ChecksumType
+	f             *os.File
+	index         *indexIterator
+	filter        *filterReader
+	dataBlockIter *blockIterator
+	readBuf       []byte
+	dataBuf       []byte
+	invalid       bool
+	err           error
+	checksumType  ChecksumType
+	comparator    Comparator
 }
 
-// NewSstFileIterator returns a new SstFileIterator.
+// NewSstFileIterator returns a new SstFileIterator, the SST file must be built with the bytewise comparator.
 func NewSstFileIterator(f *os.File) (*SstFileIterator, error) {
 	it := &SstFileIterator{
 		f:             f,
 		dataBlockIter: new(blockIterator),
+		comparator:    bytes.Compare,
 	}
 
 	if err := it.loadIndexBlock(); err != nil {
@@ -54,15 +58,93 @@ func NewSstFileIterator(f *os.File) (*SstFileIterator, error) {
 
 // SeekToFirst moves the iterator to the first key.
 func (it *SstFileIterator) SeekToFirst() {
-	it.indexBlockIter.Rewind()
 	it.invalid = false
-	if err := it.loadNextDataBlk(); err != nil {
+	if err := it.index.SeekToFirst(); err != nil {
+		it.setErr(err)
+		return
+	}
+	if err := it.loadDataBlk(); err != nil {
 		it.setErr(err)
 		return
 	}
 	it.Next()
 }
 
+// Seek moves the iterator to the first key whose user key is not less than key.
+func (it *SstFileIterator) Seek(key []byte) {
+	target := seekKey(key, maxSeqAndType)
+	it.invalid = false
+	if err := it.index.Seek(target); err != nil {
+		it.setErr(err)
+		return
+	}
+	if err := it.loadDataBlk(); err != nil {
+		it.setErr(err)
+		return
+	}
+	it.dataBlockIter.Seek(target, it.comparator)
+	// The index key may be a separator greater than the last key of the block, then all the keys in
+	// the block are less than target and the result is the first key of the next block.
+	for !it.dataBlockIter.Valid() {
+		if err := it.loadNextDataBlk(); err != nil {
+			it.setErr(err)
+			return
+		}
+		it.dataBlockIter.Next()
+	}
+}
+
+// SeekForPrev moves the iterator to the last key whose user key is not greater than key.
+func (it *SstFileIterator) SeekForPrev(key []byte) {
+	target := seekKey(key, 0)
+	it.invalid = false
+	// The first block whose last key is not less than target is the only block that may contain
+	// both the keys before and after target.
+	if err := it.index.Seek(target); err != nil {
+		if err == errEnd {
+			it.SeekToLast()
+		} else {
+			it.setErr(err)
+		}
+		return
+	}
+	if err := it.loadDataBlk(); err != nil {
+		it.setErr(err)
+		return
+	}
+	it.dataBlockIter.SeekForPrev(target, it.comparator)
+	if it.dataBlockIter.Valid() {
+		return
+	}
+	// All the keys in the block are greater than target, the result is the last key of the previous block.
+	if err := it.index.SeekForPrev(target); err != nil {
+		it.setErr(err)
+		return
+	}
+	if err := it.loadDataBlk(); err != nil {
+		it.setErr(err)
+		return
+	}
+	it.dataBlockIter.SeekToLast()
+}
+
+// SeekToLast moves the iterator to the last key.
+func (it *SstFileIterator) SeekToLast() {
+	it.invalid = false
+	if err := it.index.SeekToLast(); err != nil {
+		it.setErr(err)
+		return
+	}
+	if err := it.loadDataBlk(); err != nil {
+		it.setErr(err)
+		return
+	}
+	it.dataBlockIter.SeekToLast()
+	if !it.dataBlockIter.Valid() {
+		it.setErr(errEnd)
+	}
+}
+
 // Next moves the SstFileIterator to the next key.
 func (it *SstFileIterator) Next() {
 	if it.dataBlockIter.end() {
@@ -87,6 +169,32 @@ func (it *SstFileIterator) Value() []byte {
 	return it.dataBlockIter.Value()
 }
 
+// MayContain returns false if the key is definitely not in the SST file, it returns true if the SST
+// file has no filter or the filter is not built on the whole keys.
+func (it *SstFileIterator) MayContain(key []byte) (bool, error) {
+	if it.filter == nil {
+		return true, nil
+	}
+	return it.filter.MayContain(key)
+}
+
+// Get returns the value of the key, the filter is checked before reading the data blocks. It moves
+// the iterator, and the value is valid until the iterator moves again.
+func (it *SstFileIterator) Get(key []byte) ([]byte, bool, error) {
+	ok, err := it.MayContain(key)
+	if err != nil || !ok {
+		return nil, false, err
+	}
+	it.Seek(key)
+	if !it.Valid() {
+		return nil, false, it.Err()
+	}
+	if it.comparator(extractUserKey(it.dataBlockIter.Key()), key) != 0 {
+		return nil, false, nil
+	}
+	return it.Value(), true, nil
+}
+
 // Valid returns whether the SstFileIterator is exhausted.
 func (it *SstFileIterator) Valid() bool {
 	return !it.invalid
@@ -98,15 +206,16 @@ func (it *SstFileIterator) Err() error {
 }
 
 func (it *SstFileIterator) loadNextDataBlk() error {
-	var err error
-
-	if it.indexBlockIter.end() {
-		return errEnd
+	if err := it.index.Next(); err != nil {
+		return err
 	}
+	return it.loadDataBlk()
+}
 
-	it.indexBlockIter.Next()
-	var handle blockHandle
-	handle.Decode(it.indexBlockIter.Value())
+// loadDataBlk loads the data block of the current index entry.
+func (it *SstFileIterator) loadDataBlk() error {
+	var err error
+	handle := it.index.Handle()
 
 	it.checkReadBufSize(handle.Size + blockTrailerSize)
 	if _, err = it.f.ReadAt(it.readBuf, int64(handle.Offset)); err != nil {
@@ -134,34 +243,28 @@ func (it *SstFileIterator) decompressBlock(dst, raw []byte) ([]byte, error) {
 	blkData := raw[:trailerPos]
 	compressTp := CompressionType(raw[trailerPos])
 
-	switch it.checksumType {
-	case ChecksumCRC32:
-		crc := newCrc32()
-		crc.Write(raw[:trailerPos+1])
-		sum := crc.Sum32()
-		expected := unmaskCrc32(rocksEndian.Uint32(raw[trailerPos+1:]))
-		if expected != sum {
+	if it.checksumType != ChecksumNone {
+		sum, err := blockChecksum(it.checksumType, blkData, raw[trailerPos])
+		if err != nil {
+			return nil, err
+		}
+		if sum != rocksEndian.Uint32(raw[trailerPos+1:]) {
 			return nil, ErrChecksumMismatch
 		}
-	case ChecksumXXHash:
-		panic("unsupported")
 	}
 
 	return DecompressBlock(compressTp, blkData, dst)
 }
 
-func (it *SstFileIterator) getIndexBlockHandle() (blockHandle, error) {
-	var handle blockHandle
-
+func (it *SstFileIterator) getBlockHandles() (metaIndexHandle, indexHandle blockHandle, err error) {
 	footer, err := it.loadFooter()
 	if err != nil {
-		return handle, err
+		return
 	}
 
-	// Skip meta index handle
-	n := handle.Decode(footer[1:])
-	handle.Decode(footer[1+n:])
-	return handle, nil
+	n := metaIndexHandle.Decode(footer[1:])
+	indexHandle.Decode(footer[1+n:])
+	return
 }
 
 func (it *SstFileIterator) loadFooter() ([]byte, error) {
@@ -194,23 +297,124 @@ func (it *SstFileIterator) checkMagicNumber(footer []byte) bool {
 }
 
 func (it *SstFileIterator) loadIndexBlock() error {
-	handle, err := it.getIndexBlockHandle()
+	metaIndexHandle, indexHandle, err := it.getBlockHandles()
 	if err != nil {
 		return err
 	}
-
-	indexBlkData := make([]byte, handle.Size+blockTrailerSize)
-	if _, err = it.f.ReadAt(indexBlkData, int64(handle.Offset)); err != nil {
+	metaIndex, err := it.readMetaIndex(metaIndexHandle)
+	if err != nil {
 		return err
 	}
-	if indexBlkData, err = it.decompressBlock(nil, indexBlkData); err != nil {
+	indexType := IndexTypeBinarySearch
+	wholeKeyFiltering := true
+	if handle, ok := metaIndex[propsBlockHandleKey]; ok {
+		props, err := it.readProperties(handle)
+		if err != nil {
+			return err
+		}
+		if v, ok := props[propIndexType]; ok && len(v) == 4 {
+			indexType = IndexType(rocksEndian.Uint32(v))
+		}
+		if v, ok := props[propWholeKeyFiltering]; ok {
+			wholeKeyFiltering = string(v) == propTrue
+		}
+	}
+	if wholeKeyFiltering {
+		if err = it.loadFilter(metaIndex); err != nil {
+			return err
+		}
+	}
+
+	indexBlkData, err := it.readBlock(indexHandle)
+	if err != nil {
 		return err
 	}
-	it.indexBlockIter = newBlockIterator(indexBlkData)
+	it.index = &indexIterator{
+		cmp:       it.comparator,
+		readBlock: it.readBlock,
+	}
+	switch indexType {
+	case IndexTypeBinarySearch:
+		it.index.index = newBlockIterator(indexBlkData)
+	case IndexTypeTwoLevelSearch:
+		it.index.topLevel = newBlockIterator(indexBlkData)
+		it.index.index = new(blockIterator)
+	default:
+		return errors.Errorf("unsupported index type %d", indexType)
+	}
 
 	return nil
 }
 
+func (it *SstFileIterator) loadFilter(metaIndex map[string]blockHandle) error {
+	if handle, ok := metaIndex[bloomBlockHandleKey]; ok {
+		full, err := it.readBlock(handle)
+		if err != nil {
+			return err
+		}
+		it.filter = &filterReader{full: full}
+	} else if handle, ok := metaIndex[partitionedBloomBlockHandleKey]; ok {
+		partitionIndex, err := it.readBlock(handle)
+		if err != nil {
+			return err
+		}
+		it.filter = &filterReader{
+			partitionIndex: newBlockIterator(partitionIndex),
+			cmp:            it.comparator,
+			readBlock:      it.readBlock,
+		}
+	}
+	return nil
+}
+
+// readBlock reads and decompresses the block into a newly allocated buffer.
+func (it *SstFileIterator) readBlock(handle blockHandle) ([]byte, error) {
+	raw := make([]byte, handle.Size+blockTrailerSize)
+	if _, err := it.f.ReadAt(raw, int64(handle.Offset)); err != nil {
+		return nil, err
+	}
+	return it.decompressBlock(nil, raw)
+}
+
+func (it *SstFileIterator) readMetaIndex(handle blockHandle) (map[string]blockHandle, error) {
+	data, err := it.readBlock(handle)
+	if err != nil {
+		return nil, err
+	}
+	handles := make(map[string]blockHandle)
+	iter := newBlockIterator(data)
+	for iter.SeekToFirst(); iter.Valid(); iter.Next() {
+		var h blockHandle
+		h.Decode(iter.Value())
+		handles[string(iter.Key())] = h
+	}
+	return handles, nil
+}
+
+func (it *SstFileIterator) readProperties(handle blockHandle) (map[string][]byte, error) {
+	data, err := it.readBlock(handle)
+	if err != nil {
+		return nil, err
+	}
+	props := make(map[string][]byte)
+	iter := newBlockIterator(data)
+	for iter.SeekToFirst(); iter.Valid(); iter.Next() {
+		props[string(iter.Key())] = append([]byte(nil), iter.Value()...)
+	}
+	return props, nil
+}
+
+// maxSeqAndType is the packed sequence number and type of the first internal key of a user key.
+const maxSeqAndType = 1<<64 - 1
+
+// seekKey returns the internal key of the user key with the packed sequence number and type.
+func seekKey(userKey []byte, seqAndType uint64) []byte {
+	buf := make([]byte, len(userKey)+8)
+	copy(buf, userKey)
+	rocksEndian.PutUint64(buf[len(userKey):], seqAndType)
+	return buf
+}
+
 func (it *SstFileIterator) setErr(err error) {
 	if err != errEnd {
 		it.err = err
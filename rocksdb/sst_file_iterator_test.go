@@ -15,6 +15,7 @@ package rocksdb
 
 import (
 	"bytes"
+	"fmt"
 	"io/ioutil"
 	"os"
 	"testing"
@@ -49,6 +50,42 @@ func TestLz4Compression(t *testing.T) {
 	})
 }
 
+func TestSnappyCompression(t *testing.T) {
+	opts := NewDefaultBlockBasedTableOptions(bytes.Compare)
+	opts.CompressionType = CompressionSnappy
+
+	t.Run("small", func(t *testing.T) {
+		testSstReadWrite(t, smallTestSize, opts)
+	})
+	t.Run("large", func(t *testing.T) {
+		testSstReadWrite(t, largeTestSize, opts)
+	})
+}
+
+func TestZstdCompression(t *testing.T) {
+	opts := NewDefaultBlockBasedTableOptions(bytes.Compare)
+	opts.CompressionType = CompressionZstd
+
+	t.Run("small", func(t *testing.T) {
+		testSstReadWrite(t, smallTestSize, opts)
+	})
+	t.Run("large", func(t *testing.T) {
+		testSstReadWrite(t, largeTestSize, opts)
+	})
+}
+
+func TestCompressBlock(t *testing.T) {
+	input := bytes.Repeat([]byte("unistore"), 1024)
+	for _, tp := range []CompressionType{CompressionSnappy, CompressionLz4, CompressionZstd} {
+		compressed, ok := CompressBlock(tp, input, nil)
+		require.True(t, ok, tp.String())
+		require.True(t, len(compressed) < len(input), tp.String())
+		decompressed, err := DecompressBlock(tp, compressed, nil)
+		require.Nil(t, err, tp.String())
+		require.Equal(t, input, decompressed, tp.String())
+	}
+}
+
 func TestBlockAlign(t *testing.T) {
 	opts := NewDefaultBlockBasedTableOptions(bytes.Compare)
 	opts.CompressionType = CompressionLz4
@@ -74,6 +111,34 @@ func TestNoChecksum(t *testing.T) {
 	})
 }
 
+func TestChecksum(t *testing.T) {
+	for _, tp := range []ChecksumType{ChecksumXXHash, ChecksumXXHash64, ChecksumXXH3} {
+		opts := NewDefaultBlockBasedTableOptions(bytes.Compare)
+		opts.CompressionType = CompressionLz4
+		opts.ChecksumType = tp
+		testSstReadWrite(t, largeTestSize, opts)
+	}
+}
+
+func TestChecksumMismatch(t *testing.T) {
+	opts := NewDefaultBlockBasedTableOptions(bytes.Compare)
+	opts.ChecksumType = ChecksumXXH3
+	f, remove := tempSstFile(t)
+	defer remove()
+	w := NewSstFileWriter(f, opts)
+	require.Nil(t, w.Put([]byte("a"), []byte("b")))
+	require.Nil(t, w.Finish())
+	// Corrupt the value in the first data block.
+	_, err := f.WriteAt([]byte("c"), 10)
+	require.Nil(t, err)
+
+	it, err := NewSstFileIterator(f)
+	require.Nil(t, err)
+	it.SeekToFirst()
+	require.False(t, it.Valid())
+	require.Equal(t, ErrChecksumMismatch, it.Err())
+}
+
 func testSstReadWrite(t *testing.T, num int, opts *BlockBasedTableOptions) {
 	nums := sortedNumbers(num)
 	f, err := ioutil.TempFile("", "unistore-test.*.sst")
@@ -106,3 +171,209 @@ func testSstReadWrite(t *testing.T, num int, opts *BlockBasedTableOptions) {
 		require.Nil(t, it.Err())
 	}
 }
+
+func TestSstSeek(t *testing.T) {
+	opts := NewDefaultBlockBasedTableOptions(bytes.Compare)
+	opts.CompressionType = CompressionLz4
+	f, remove := tempSstFile(t)
+	defer remove()
+	testSstSeek(t, f, opts)
+}
+
+func TestPartitionedIndex(t *testing.T) {
+	opts := NewDefaultBlockBasedTableOptions(bytes.Compare)
+	opts.CompressionType = CompressionLz4
+	opts.IndexType = IndexTypeTwoLevelSearch
+	opts.PartitionFilters = true
+	opts.MetadataBlockSize = 256
+
+	t.Run("small", func(t *testing.T) {
+		testSstReadWrite(t, smallTestSize, opts)
+	})
+	t.Run("large", func(t *testing.T) {
+		testSstReadWrite(t, largeTestSize, opts)
+	})
+	t.Run("seek", func(t *testing.T) {
+		f, remove := tempSstFile(t)
+		defer remove()
+		it := testSstSeek(t, f, opts)
+		metaIndexHandle, _, err := it.getBlockHandles()
+		require.Nil(t, err)
+		metaIndex, err := it.readMetaIndex(metaIndexHandle)
+		require.Nil(t, err)
+		require.Contains(t, metaIndex, partitionedBloomBlockHandleKey)
+		props, err := it.readProperties(metaIndex[propsBlockHandleKey])
+		require.Nil(t, err)
+		partitions, _ := decodeVarint64(props[propIndexPartitions])
+		require.True(t, partitions > 1)
+		require.Equal(t, uint32(IndexTypeTwoLevelSearch), rocksEndian.Uint32(props[propIndexType]))
+	})
+}
+
+// Keys of the seek tests are the even numbers in [0, 2*seekTestNum).
+const seekTestNum = 5000
+
+func seekTestKey(i int) []byte {
+	return []byte(fmt.Sprintf("%08d", i))
+}
+
+func testSstSeek(t *testing.T, f *os.File, opts *BlockBasedTableOptions) *SstFileIterator {
+	w := NewSstFileWriter(f, opts)
+	for i := 0; i < seekTestNum; i++ {
+		require.Nil(t, w.Put(seekTestKey(2*i), seekTestKey(2*i)))
+	}
+	require.Nil(t, w.Finish())
+	return checkSstSeek(t, f)
+}
+
+func checkSstSeek(t *testing.T, f *os.File) *SstFileIterator {
+	const num = seekTestNum
+	key := seekTestKey
+	it, err := NewSstFileIterator(f)
+	require.Nil(t, err)
+	for _, i := range []int{0, 1, 2, 99, 100, 1001, 5555, 2*num - 3, 2*num - 2} {
+		expected := (i + 1) / 2 * 2
+		it.Seek(key(i))
+		require.True(t, it.Valid(), i)
+		require.Equal(t, key(expected), it.Key().UserKey, i)
+		require.Equal(t, key(expected), it.Value(), i)
+		// The iterator keeps going forward after seek.
+		cnt := 0
+		for ; it.Valid(); it.Next() {
+			require.Equal(t, key(expected+2*cnt), it.Key().UserKey, i)
+			cnt++
+		}
+		require.Equal(t, num-expected/2, cnt, i)
+
+		expected = i / 2 * 2
+		it.SeekForPrev(key(i))
+		require.True(t, it.Valid(), i)
+		require.Equal(t, key(expected), it.Key().UserKey, i)
+		it.Next()
+		if expected == 2*num-2 {
+			require.False(t, it.Valid(), i)
+		} else {
+			require.Equal(t, key(expected+2), it.Key().UserKey, i)
+		}
+	}
+	it.Seek(key(2*num - 1))
+	require.False(t, it.Valid())
+	it.SeekForPrev([]byte("0"))
+	require.False(t, it.Valid())
+	it.SeekForPrev(key(3 * num))
+	require.True(t, it.Valid())
+	require.Equal(t, key(2*num-2), it.Key().UserKey)
+	it.SeekToLast()
+	require.True(t, it.Valid())
+	require.Equal(t, key(2*num-2), it.Key().UserKey)
+	it.Next()
+	require.False(t, it.Valid())
+	require.Nil(t, it.Err())
+	return it
+}
+
+// writeSeparatorIndexSst writes the seek test keys with the index keys being separators between the
+// data blocks like RocksDB does, the separator is greater than the last key of the block, and the odd
+// number after it is in neither block.
+func writeSeparatorIndexSst(t *testing.T, f *os.File, opts *BlockBasedTableOptions) {
+	b := NewBlockBasedTableBuilder(f, opts)
+	for i := 0; i < seekTestNum; i++ {
+		ikey := InternalKey{UserKey: seekTestKey(2 * i), ValueType: TypeValue}
+		key := ikey.Encode()
+		if b.shouldFlush(key, ikey.UserKey) {
+			require.Nil(t, b.flush())
+			lastKey := b.lastKey
+			separator := append(seekTestKey(2*i-1), 0xff)
+			b.lastKey = seekKey(separator, maxSeqAndType)
+			b.addIndexEntry()
+			b.lastKey = lastKey
+		}
+		require.Nil(t, b.Add(key, ikey.UserKey))
+	}
+	require.Nil(t, b.Finish())
+}
+
+func TestSstSeekSeparatorIndex(t *testing.T) {
+	partitioned := NewDefaultBlockBasedTableOptions(bytes.Compare)
+	partitioned.IndexType = IndexTypeTwoLevelSearch
+	partitioned.PartitionFilters = true
+	partitioned.MetadataBlockSize = 256
+	for _, opts := range []*BlockBasedTableOptions{NewDefaultBlockBasedTableOptions(bytes.Compare), partitioned} {
+		f, remove := tempSstFile(t)
+		writeSeparatorIndexSst(t, f, opts)
+		it := checkSstSeek(t, f)
+		// Seek to the key between the last key of a block and the separator.
+		for i := 1; i < 2*seekTestNum-1; i += 2 {
+			it.Seek(seekTestKey(i))
+			require.True(t, it.Valid(), i)
+			require.Equal(t, seekTestKey(i+1), it.Key().UserKey, i)
+			val, ok, err := it.Get(seekTestKey(i + 1))
+			require.Nil(t, err)
+			require.True(t, ok, i)
+			require.Equal(t, seekTestKey(i+1), val, i)
+		}
+		remove()
+	}
+}
+
+func tempSstFile(t *testing.T) (*os.File, func()) {
+	f, err := ioutil.TempFile("", "unistore-test.*.sst")
+	require.Nil(t, err)
+	return f, func() {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+	}
+}
+
+func TestSstGet(t *testing.T) {
+	full := NewDefaultBlockBasedTableOptions(bytes.Compare)
+	partitioned := NewDefaultBlockBasedTableOptions(bytes.Compare)
+	partitioned.IndexType = IndexTypeTwoLevelSearch
+	partitioned.PartitionFilters = true
+	partitioned.MetadataBlockSize = 256
+	noFilter := NewDefaultBlockBasedTableOptions(bytes.Compare)
+	noFilter.BloomBitsPerKey = 0
+	for _, opts := range []*BlockBasedTableOptions{full, partitioned, noFilter} {
+		testSstGet(t, opts)
+	}
+}
+
+func testSstGet(t *testing.T, opts *BlockBasedTableOptions) {
+	// Keys are the even numbers in [0, 2*num).
+	const num = 5000
+	key := func(i int) []byte {
+		return []byte(fmt.Sprintf("%08d", i))
+	}
+	f, remove := tempSstFile(t)
+	defer remove()
+	w := NewSstFileWriter(f, opts)
+	for i := 0; i < num; i++ {
+		require.Nil(t, w.Put(key(2*i), key(2*i)))
+	}
+	require.Nil(t, w.Finish())
+
+	it, err := NewSstFileIterator(f)
+	require.Nil(t, err)
+	var filtered int
+	for i := 0; i < 2*num+10; i++ {
+		val, ok, err := it.Get(key(i))
+		require.Nil(t, err)
+		if i%2 == 0 && i < 2*num {
+			require.True(t, ok, i)
+			require.Equal(t, key(i), val, i)
+			continue
+		}
+		require.False(t, ok, i)
+		mayContain, err := it.MayContain(key(i))
+		require.Nil(t, err)
+		if !mayContain {
+			filtered++
+		}
+	}
+	if opts.BloomBitsPerKey == 0 {
+		require.Equal(t, 0, filtered)
+	} else {
+		// With 10 bits per key, the false positive rate is about 1%.
+		require.True(t, filtered > num*9/10, filtered)
+	}
+}
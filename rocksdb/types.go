@@ -66,6 +66,8 @@ func (c Comparator) CompareInternalKey(key1, key2 []byte) int {
 type TableProperties struct {
 	DataSize            uint64
 	IndexSize           uint64
+	IndexPartitions     uint64
+	TopLevelIndexSize   uint64
 	FilterSize          uint64
 	RawKeySize          uint64
 	RawValueSize        uint64
@@ -128,11 +128,6 @@ func maskCrc32(sum uint32) uint32 {
 	return ((sum >> 15) | (sum << 17)) + crc32MaskDelta
 }
 
-func unmaskCrc32(sum uint32) uint32 {
-	rot := sum - crc32MaskDelta
-	return (rot >> 17) | (rot << 15)
-}
-
 func newCrc32() hash.Hash32 {
 	return crc32.New(rocksCrcTable)
 }
@@ -146,16 +141,15 @@ func rocksHash(data []byte, seed uint32) uint32 {
 	const r = 24
 	h := seed ^ uint32(len(data)*m)
 
-	pos := 0
-	for ; pos+4 < len(data); pos += 4 {
-		w := rocksEndian.Uint32(data[pos : pos+4])
+	for ; len(data) >= 4; data = data[4:] {
+		w := rocksEndian.Uint32(data)
 		h += w
 		h *= m
 		h ^= h >> 16
 	}
 
 	// Pick up remaining bytes
-	remain := len(data) - pos
+	remain := len(data)
 	if remain == 3 {
 		h += uint32(int8(data[2])) << 16
 	}
@@ -0,0 +1,256 @@
+// Copyright 2019-present PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rocksdb
+
+import "math/bits"
+
+// xxh32 and xxh3 implement XXH32 and XXH3_64bits of xxHash (https://github.com/Cyan4973/xxHash) with seed 0,
+// they are used by the block checksums. XXH64 is provided by github.com/cespare/xxhash.
+
+const (
+	prime32x1 uint32 = 0x9e3779b1
+	prime32x2 uint32 = 0x85ebca77
+	prime32x3 uint32 = 0xc2b2ae3d
+	prime32x4 uint32 = 0x27d4eb2f
+	prime32x5 uint32 = 0x165667b1
+
+	prime64x1 uint64 = 0x9e3779b185ebca87
+	prime64x2 uint64 = 0xc2b2ae3d27d4eb4f
+	prime64x3 uint64 = 0x165667b19e3779f9
+	prime64x4 uint64 = 0x85ebca77c2b2ae63
+	prime64x5 uint64 = 0x27d4eb2f165667c5
+)
+
+func xxh32Round(acc, input uint32) uint32 {
+	acc += input * prime32x2
+	return bits.RotateLeft32(acc, 13) * prime32x1
+}
+
+// xxh32 returns XXH32 of data, the last byte is appended to data.
+func xxh32(data []byte, lastByte byte) uint32 {
+	n := len(data) + 1
+	var seed uint32
+	v1 := seed + prime32x1 + prime32x2
+	v2 := seed + prime32x2
+	v3 := seed
+	v4 := seed - prime32x1
+	round := func(stripe []byte) {
+		v1 = xxh32Round(v1, rocksEndian.Uint32(stripe))
+		v2 = xxh32Round(v2, rocksEndian.Uint32(stripe[4:]))
+		v3 = xxh32Round(v3, rocksEndian.Uint32(stripe[8:]))
+		v4 = xxh32Round(v4, rocksEndian.Uint32(stripe[12:]))
+	}
+	for ; len(data) >= 16; data = data[16:] {
+		round(data)
+	}
+	var tail [16]byte
+	copy(tail[:], data)
+	tail[len(data)] = lastByte
+	rest := tail[:len(data)+1]
+	if len(rest) == 16 {
+		round(rest)
+		rest = rest[16:]
+	}
+
+	var h uint32
+	if n >= 16 {
+		h = bits.RotateLeft32(v1, 1) + bits.RotateLeft32(v2, 7) + bits.RotateLeft32(v3, 12) + bits.RotateLeft32(v4, 18)
+	} else {
+		h = seed + prime32x5
+	}
+	h += uint32(n)
+	for ; len(rest) >= 4; rest = rest[4:] {
+		h += rocksEndian.Uint32(rest) * prime32x3
+		h = bits.RotateLeft32(h, 17) * prime32x4
+	}
+	for _, b := range rest {
+		h += uint32(b) * prime32x5
+		h = bits.RotateLeft32(h, 11) * prime32x1
+	}
+
+	h ^= h >> 15
+	h *= prime32x2
+	h ^= h >> 13
+	h *= prime32x3
+	h ^= h >> 16
+	return h
+}
+
+const (
+	xxh3StripeLen       = 64
+	xxh3SecretConsume   = 8
+	xxh3SecretSize      = 192
+	xxh3StripesPerBlock = (xxh3SecretSize - xxh3StripeLen) / xxh3SecretConsume
+	xxh3BlockLen        = xxh3StripeLen * xxh3StripesPerBlock
+	xxh3MidSizeMax      = 240
+)
+
+// xxh3Secret is the default secret of XXH3.
+var xxh3Secret = [xxh3SecretSize]byte{
+	0xb8, 0xfe, 0x6c, 0x39, 0x23, 0xa4, 0x4b, 0xbe, 0x7c, 0x01, 0x81, 0x2c, 0xf7, 0x21, 0xad, 0x1c,
+	0xde, 0xd4, 0x6d, 0xe9, 0x83, 0x90, 0x97, 0xdb, 0x72, 0x40, 0xa4, 0xa4, 0xb7, 0xb3, 0x67, 0x1f,
+	0xcb, 0x79, 0xe6, 0x4e, 0xcc, 0xc0, 0xe5, 0x78, 0x82, 0x5a, 0xd0, 0x7d, 0xcc, 0xff, 0x72, 0x21,
+	0xb8, 0x08, 0x46, 0x74, 0xf7, 0x43, 0x24, 0x8e, 0xe0, 0x35, 0x90, 0xe6, 0x81, 0x3a, 0x26, 0x4c,
+	0x3c, 0x28, 0x52, 0xbb, 0x91, 0xc3, 0x00, 0xcb, 0x88, 0xd0, 0x65, 0x8b, 0x1b, 0x53, 0x2e, 0xa3,
+	0x71, 0x64, 0x48, 0x97, 0xa2, 0x0d, 0xf9, 0x4e, 0x38, 0x19, 0xef, 0x46, 0xa9, 0xde, 0xac, 0xd8,
+	0xa8, 0xfa, 0x76, 0x3f, 0xe3, 0x9c, 0x34, 0x3f, 0xf9, 0xdc, 0xbb, 0xc7, 0xc7, 0x0b, 0x4f, 0x1d,
+	0x8a, 0x51, 0xe0, 0x4b, 0xcd, 0xb4, 0x59, 0x31, 0xc8, 0x9f, 0x7e, 0xc9, 0xd9, 0x78, 0x73, 0x64,
+	0xea, 0xc5, 0xac, 0x83, 0x34, 0xd3, 0xeb, 0xc3, 0xc5, 0x81, 0xa0, 0xff, 0xfa, 0x13, 0x63, 0xeb,
+	0x17, 0x0d, 0xdd, 0x51, 0xb7, 0xf0, 0xda, 0x49, 0xd3, 0x16, 0x55, 0x26, 0x29, 0xd4, 0x68, 0x9e,
+	0x2b, 0x16, 0xbe, 0x58, 0x7d, 0x47, 0xa1, 0xfc, 0x8f, 0xf8, 0xb8, 0xd1, 0x7a, 0xd0, 0x31, 0xce,
+	0x45, 0xcb, 0x3a, 0x8f, 0x95, 0x16, 0x04, 0x28, 0xaf, 0xd7, 0xfb, 0xca, 0xbb, 0x4b, 0x40, 0x7e,
+}
+
+func xxh3Avalanche(h uint64) uint64 {
+	h ^= h >> 37
+	h *= 0x165667919e3779f9
+	h ^= h >> 32
+	return h
+}
+
+func xxh64Avalanche(h uint64) uint64 {
+	h ^= h >> 33
+	h *= prime64x2
+	h ^= h >> 29
+	h *= prime64x3
+	h ^= h >> 32
+	return h
+}
+
+func xxh3Rrmxmx(h, n uint64) uint64 {
+	h ^= bits.RotateLeft64(h, 49) ^ bits.RotateLeft64(h, 24)
+	h *= 0x9fb21c651e98df25
+	h ^= (h >> 35) + n
+	h *= 0x9fb21c651e98df25
+	h ^= h >> 28
+	return h
+}
+
+func mul128Fold64(a, b uint64) uint64 {
+	hi, lo := bits.Mul64(a, b)
+	return hi ^ lo
+}
+
+func xxh3Mix16(data, secret []byte) uint64 {
+	return mul128Fold64(
+		rocksEndian.Uint64(data)^rocksEndian.Uint64(secret),
+		rocksEndian.Uint64(data[8:])^rocksEndian.Uint64(secret[8:]))
+}
+
+// xxh3 returns XXH3_64bits of data.
+func xxh3(data []byte) uint64 {
+	n := len(data)
+	secret := xxh3Secret[:]
+	switch {
+	case n == 0:
+		return xxh64Avalanche(rocksEndian.Uint64(secret[56:]) ^ rocksEndian.Uint64(secret[64:]))
+	case n <= 3:
+		c1, c2, c3 := uint32(data[0]), uint32(data[n>>1]), uint32(data[n-1])
+		combined := c1<<16 | c2<<24 | c3 | uint32(n)<<8
+		bitflip := uint64(rocksEndian.Uint32(secret) ^ rocksEndian.Uint32(secret[4:]))
+		return xxh64Avalanche(uint64(combined) ^ bitflip)
+	case n <= 8:
+		input1 := uint64(rocksEndian.Uint32(data))
+		input2 := uint64(rocksEndian.Uint32(data[n-4:]))
+		bitflip := rocksEndian.Uint64(secret[8:]) ^ rocksEndian.Uint64(secret[16:])
+		return xxh3Rrmxmx((input2+input1<<32)^bitflip, uint64(n))
+	case n <= 16:
+		inputLo := rocksEndian.Uint64(data) ^ rocksEndian.Uint64(secret[24:]) ^ rocksEndian.Uint64(secret[32:])
+		inputHi := rocksEndian.Uint64(data[n-8:]) ^ rocksEndian.Uint64(secret[40:]) ^ rocksEndian.Uint64(secret[48:])
+		acc := uint64(n) + bits.ReverseBytes64(inputLo) + inputHi + mul128Fold64(inputLo, inputHi)
+		return xxh3Avalanche(acc)
+	case n <= 128:
+		acc := uint64(n) * prime64x1
+		if n > 32 {
+			if n > 64 {
+				if n > 96 {
+					acc += xxh3Mix16(data[48:], secret[96:])
+					acc += xxh3Mix16(data[n-64:], secret[112:])
+				}
+				acc += xxh3Mix16(data[32:], secret[64:])
+				acc += xxh3Mix16(data[n-48:], secret[80:])
+			}
+			acc += xxh3Mix16(data[16:], secret[32:])
+			acc += xxh3Mix16(data[n-32:], secret[48:])
+		}
+		acc += xxh3Mix16(data, secret)
+		acc += xxh3Mix16(data[n-16:], secret[16:])
+		return xxh3Avalanche(acc)
+	case n <= xxh3MidSizeMax:
+		acc := uint64(n) * prime64x1
+		for i := 0; i < 8; i++ {
+			acc += xxh3Mix16(data[16*i:], secret[16*i:])
+		}
+		acc = xxh3Avalanche(acc)
+		for i := 8; i < n/16; i++ {
+			acc += xxh3Mix16(data[16*i:], secret[16*(i-8)+3:])
+		}
+		acc += xxh3Mix16(data[n-16:], secret[136-17:])
+		return xxh3Avalanche(acc)
+	default:
+		return xxh3Long(data)
+	}
+}
+
+func xxh3Long(data []byte) uint64 {
+	n := len(data)
+	secret := xxh3Secret[:]
+	acc := [8]uint64{
+		uint64(prime32x3), prime64x1, prime64x2, prime64x3,
+		prime64x4, uint64(prime32x2), prime64x5, uint64(prime32x1),
+	}
+
+	numBlocks := (n - 1) / xxh3BlockLen
+	for b := 0; b < numBlocks; b++ {
+		block := data[b*xxh3BlockLen:]
+		for s := 0; s < xxh3StripesPerBlock; s++ {
+			xxh3Accumulate512(&acc, block[s*xxh3StripeLen:], secret[s*xxh3SecretConsume:])
+		}
+		xxh3ScrambleAcc(&acc, secret[xxh3SecretSize-xxh3StripeLen:])
+	}
+
+	numStripes := ((n - 1) - xxh3BlockLen*numBlocks) / xxh3StripeLen
+	block := data[numBlocks*xxh3BlockLen:]
+	for s := 0; s < numStripes; s++ {
+		xxh3Accumulate512(&acc, block[s*xxh3StripeLen:], secret[s*xxh3SecretConsume:])
+	}
+	xxh3Accumulate512(&acc, data[n-xxh3StripeLen:], secret[xxh3SecretSize-xxh3StripeLen-7:])
+
+	result := uint64(n) * prime64x1
+	for i := 0; i < 4; i++ {
+		result += mul128Fold64(
+			acc[2*i]^rocksEndian.Uint64(secret[11+16*i:]),
+			acc[2*i+1]^rocksEndian.Uint64(secret[11+16*i+8:]))
+	}
+	return xxh3Avalanche(result)
+}
+
+func xxh3Accumulate512(acc *[8]uint64, data, secret []byte) {
+	for i := 0; i < 8; i++ {
+		dataVal := rocksEndian.Uint64(data[8*i:])
+		dataKey := dataVal ^ rocksEndian.Uint64(secret[8*i:])
+		acc[i^1] += dataVal
+		acc[i] += (dataKey & 0xffffffff) * (dataKey >> 32)
+	}
+}
+
+func xxh3ScrambleAcc(acc *[8]uint64, secret []byte) {
+	for i := 0; i < 8; i++ {
+		a := acc[i]
+		a ^= a >> 47
+		a ^= rocksEndian.Uint64(secret[8*i:])
+		a *= uint64(prime32x1)
+		acc[i] = a
+	}
+}
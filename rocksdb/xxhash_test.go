@@ -0,0 +1,58 @@
+// Copyright 2019-present PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rocksdb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestXXHash(t *testing.T) {
+	require.Equal(t, uint32(0x32d153ff), xxh32([]byte("ab"), 'c'))
+
+	// The input bytes are (i+1)%251, the expected values are from the reference implementation,
+	// xxh32 hashes one more byte.
+	cases := []struct {
+		n     int
+		xxh3  uint64
+		xxh32 uint32
+	}{
+		{0, 0x2d06800538d394c2, 0x3892f731},
+		{1, 0xe12ef9d2eb86ceeb, 0xabca9c18},
+		{2, 0x08130b77ddef5807, 0xf59c78c4},
+		{3, 0xebce9b7632ae733b, 0xfe96d19c},
+		{4, 0x988b7b9033ac4622, 0xdf1c16c8},
+		{8, 0x16f217ea16232297, 0x91a5bbed},
+		{9, 0x17d143e7f447850a, 0x6d9579bc},
+		{16, 0xeb5aeb9a32450f6a, 0x9e946d6c},
+		{17, 0x6d458e1fff494078, 0xd02cbfe3},
+		{100, 0xd53e74fac84fa8fb, 0x061408db},
+		{128, 0xce22cae9106851df, 0xe5d82a6a},
+		{129, 0x7d4fc663f5958d40, 0x43a1d1a8},
+		{240, 0xa5a910b2d7e065b0, 0x36887ac0},
+		{241, 0xb6515f490cdd4ce5, 0xdb3b864a},
+		{1024, 0x546f61a5b0b850c1, 0x10ef4622},
+		{1025, 0xa58696e72de6df58, 0xce4b2ccf},
+		{2049, 0x2472452777c6f8d3, 0xff2e39f2},
+	}
+	buf := make([]byte, 4096)
+	for i := range buf {
+		buf[i] = byte((i + 1) % 251)
+	}
+	for _, c := range cases {
+		require.Equal(t, c.xxh3, xxh3(buf[:c.n]), c.n)
+		require.Equal(t, c.xxh32, xxh32(buf[:c.n], buf[c.n]), c.n)
+	}
+}
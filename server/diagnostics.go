@@ -0,0 +1,329 @@
+// Copyright 2019-present PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/ngaut/unistore/config"
+	"github.com/pingcap/errors"
+	"github.com/pingcap/kvproto/pkg/diagnosticspb"
+	"github.com/shirou/gopsutil/cpu"
+	"github.com/shirou/gopsutil/disk"
+	"github.com/shirou/gopsutil/host"
+	"github.com/shirou/gopsutil/load"
+	"github.com/shirou/gopsutil/mem"
+)
+
+const (
+	logTimeFormat      = "2006/01/02 15:04:05.000 -07:00"
+	searchLogBatchSize = 1024
+)
+
+// DiagnosticsServer implements the diagnostics service queried by TiDB's information_schema.cluster_* tables.
+type DiagnosticsServer struct {
+	logFile string
+	dbPath  string
+}
+
+// NewDiagnosticsServer returns a new DiagnosticsServer.
+func NewDiagnosticsServer(conf *config.Config) *DiagnosticsServer {
+	return &DiagnosticsServer{
+		logFile: conf.Server.LogfilePath,
+		dbPath:  conf.Engine.DBPath,
+	}
+}
+
+// SearchLog implements the diagnosticspb.DiagnosticsServer SearchLog method.
+// Only the current log file is searched, slow logs are not supported.
+func (s *DiagnosticsServer) SearchLog(req *diagnosticspb.SearchLogRequest, stream diagnosticspb.Diagnostics_SearchLogServer) error {
+	if req.Target == diagnosticspb.SearchLogRequest_Slow || s.logFile == "" {
+		return nil
+	}
+	patterns := make([]*regexp.Regexp, 0, len(req.Patterns))
+	for _, p := range req.Patterns {
+		re, err := regexp.Compile("(?i)" + p)
+		if err != nil {
+			return errors.Errorf("invalid pattern %s: %v", p, err)
+		}
+		patterns = append(patterns, re)
+	}
+	f, err := os.Open(s.logFile)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer f.Close()
+
+	var (
+		batch = make([]*diagnosticspb.LogMessage, 0, searchLogBatchSize)
+		last  *diagnosticspb.LogMessage
+	)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		err := stream.Send(&diagnosticspb.SearchLogResponse{Messages: batch})
+		batch = make([]*diagnosticspb.LogMessage, 0, searchLogBatchSize)
+		return err
+	}
+	accept := func(msg *diagnosticspb.LogMessage) error {
+		if msg == nil || !matchLogMessage(req, patterns, msg) {
+			return nil
+		}
+		batch = append(batch, msg)
+		if len(batch) >= searchLogBatchSize {
+			return flush()
+		}
+		return nil
+	}
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		msg, ok := parseLogLine(line)
+		if !ok {
+			// Lines without a header belong to the previous message, e.g. a stack trace.
+			if last != nil {
+				last.Message += "\n" + line
+			}
+			continue
+		}
+		if err := accept(last); err != nil {
+			return err
+		}
+		last = msg
+	}
+	if err := scanner.Err(); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := accept(last); err != nil {
+		return err
+	}
+	return flush()
+}
+
+func matchLogMessage(req *diagnosticspb.SearchLogRequest, patterns []*regexp.Regexp, msg *diagnosticspb.LogMessage) bool {
+	if req.StartTime > 0 && msg.Time < req.StartTime {
+		return false
+	}
+	if req.EndTime > 0 && msg.Time > req.EndTime {
+		return false
+	}
+	if len(req.Levels) > 0 {
+		found := false
+		for _, level := range req.Levels {
+			if level == msg.Level {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	for _, re := range patterns {
+		if !re.MatchString(msg.Message) {
+			return false
+		}
+	}
+	return true
+}
+
+// parseLogLine parses a line in the format of "[2006/01/02 15:04:05.000 -07:00] [INFO] ...",
+// the returned message time is in milliseconds and the message doesn't contain the time and level.
+func parseLogLine(line string) (*diagnosticspb.LogMessage, bool) {
+	if len(line) < len(logTimeFormat)+2 || line[0] != '[' || line[len(logTimeFormat)+1] != ']' {
+		return nil, false
+	}
+	t, err := time.Parse(logTimeFormat, line[1:len(logTimeFormat)+1])
+	if err != nil {
+		return nil, false
+	}
+	rest := strings.TrimLeft(line[len(logTimeFormat)+2:], " ")
+	end := strings.IndexByte(rest, ']')
+	if len(rest) == 0 || rest[0] != '[' || end < 0 {
+		return nil, false
+	}
+	return &diagnosticspb.LogMessage{
+		Time:    t.UnixNano() / int64(time.Millisecond),
+		Level:   parseLogLevel(rest[1:end]),
+		Message: strings.TrimLeft(rest[end+1:], " "),
+	}, true
+}
+
+func parseLogLevel(s string) diagnosticspb.LogLevel {
+	switch strings.ToUpper(s) {
+	case "DEBUG":
+		return diagnosticspb.LogLevel_Debug
+	case "INFO":
+		return diagnosticspb.LogLevel_Info
+	case "WARN", "WARNING":
+		return diagnosticspb.LogLevel_Warn
+	case "TRACE":
+		return diagnosticspb.LogLevel_Trace
+	case "FATAL", "CRITICAL", "PANIC", "DPANIC":
+		return diagnosticspb.LogLevel_Critical
+	case "ERROR":
+		return diagnosticspb.LogLevel_Error
+	}
+	return diagnosticspb.LogLevel_UNKNOWN
+}
+
+// ServerInfo implements the diagnosticspb.DiagnosticsServer ServerInfo method.
+func (s *DiagnosticsServer) ServerInfo(ctx context.Context, req *diagnosticspb.ServerInfoRequest) (*diagnosticspb.ServerInfoResponse, error) {
+	var items []*diagnosticspb.ServerInfoItem
+	var err error
+	switch req.Tp {
+	case diagnosticspb.ServerInfoType_HardwareInfo:
+		items, err = s.hardwareInfo()
+	case diagnosticspb.ServerInfoType_SystemInfo:
+		items, err = s.systemInfo()
+	case diagnosticspb.ServerInfoType_LoadInfo:
+		items, err = s.loadInfo()
+	case diagnosticspb.ServerInfoType_All:
+		for _, f := range []func() ([]*diagnosticspb.ServerInfoItem, error){s.hardwareInfo, s.systemInfo, s.loadInfo} {
+			var part []*diagnosticspb.ServerInfoItem
+			if part, err = f(); err != nil {
+				break
+			}
+			items = append(items, part...)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &diagnosticspb.ServerInfoResponse{Items: items}, nil
+}
+
+func newServerInfoItem(tp, name string, pairs ...string) *diagnosticspb.ServerInfoItem {
+	item := &diagnosticspb.ServerInfoItem{Tp: tp, Name: name}
+	for i := 0; i+1 < len(pairs); i += 2 {
+		item.Pairs = append(item.Pairs, &diagnosticspb.ServerInfoPair{Key: pairs[i], Value: pairs[i+1]})
+	}
+	return item
+}
+
+func (s *DiagnosticsServer) hardwareInfo() ([]*diagnosticspb.ServerInfoItem, error) {
+	logical, err := cpu.Counts(true)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	physical, err := cpu.Counts(false)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	cpuPairs := []string{
+		"cpu-logical-cores", fmt.Sprint(logical),
+		"cpu-physical-cores", fmt.Sprint(physical),
+	}
+	if infos, err := cpu.Info(); err == nil && len(infos) > 0 {
+		cpuPairs = append(cpuPairs,
+			"cpu-frequency", fmt.Sprintf("%.2fMHz", infos[0].Mhz),
+			"model-name", infos[0].ModelName)
+	}
+	vm, err := mem.VirtualMemory()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	items := []*diagnosticspb.ServerInfoItem{
+		newServerInfoItem("cpu", "cpu", cpuPairs...),
+		newServerInfoItem("memory", "memory", "capacity", fmt.Sprint(vm.Total)),
+	}
+	if s.dbPath != "" {
+		usage, err := disk.Usage(s.dbPath)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		items = append(items, newServerInfoItem("disk", usage.Path,
+			"path", usage.Path,
+			"fstype", usage.Fstype,
+			"total", fmt.Sprint(usage.Total),
+			"free", fmt.Sprint(usage.Free),
+			"used", fmt.Sprint(usage.Used),
+			"used-percent", fmt.Sprintf("%.2f", usage.UsedPercent)))
+	}
+	return items, nil
+}
+
+func (s *DiagnosticsServer) systemInfo() ([]*diagnosticspb.ServerInfoItem, error) {
+	info, err := host.Info()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return []*diagnosticspb.ServerInfoItem{
+		newServerInfoItem("system", "os",
+			"os", info.OS,
+			"platform", info.Platform,
+			"platform-version", info.PlatformVersion,
+			"kernel-version", info.KernelVersion,
+			"hostname", info.Hostname),
+		newServerInfoItem("system", "process",
+			"go-version", runtime.Version(),
+			"pid", fmt.Sprint(os.Getpid())),
+	}, nil
+}
+
+func (s *DiagnosticsServer) loadInfo() ([]*diagnosticspb.ServerInfoItem, error) {
+	avg, err := load.Avg()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	vm, err := mem.VirtualMemory()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	items := []*diagnosticspb.ServerInfoItem{
+		newServerInfoItem("cpu", "cpu",
+			"load1", fmt.Sprintf("%.2f", avg.Load1),
+			"load5", fmt.Sprintf("%.2f", avg.Load5),
+			"load15", fmt.Sprintf("%.2f", avg.Load15)),
+		newServerInfoItem("memory", "virtual",
+			"total", fmt.Sprint(vm.Total),
+			"used", fmt.Sprint(vm.Used),
+			"free", fmt.Sprint(vm.Available),
+			"used-percent", fmt.Sprintf("%.2f", vm.UsedPercent)),
+	}
+	for _, subPath := range []string{subPathKV, subPathRaft} {
+		size, files, err := dirSize(filepath.Join(s.dbPath, subPath))
+		if err != nil {
+			continue
+		}
+		items = append(items, newServerInfoItem("engine", subPath,
+			"size", fmt.Sprint(size),
+			"files", fmt.Sprint(files)))
+	}
+	return items, nil
+}
+
+func dirSize(dir string) (size int64, files int, err error) {
+	err = filepath.Walk(dir, func(_ string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			size += info.Size()
+			files++
+		}
+		return nil
+	})
+	return
+}
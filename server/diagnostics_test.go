@@ -0,0 +1,41 @@
+// Copyright 2019-present PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/pingcap/kvproto/pkg/diagnosticspb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseLogLine(t *testing.T) {
+	line := `[2021/04/07 10:47:00.123 +08:00] [WARN] [region.go:176] ["region 2 acquire 1 locks takes 60ms"]`
+	msg, ok := parseLogLine(line)
+	assert.True(t, ok)
+	assert.Equal(t, diagnosticspb.LogLevel_Warn, msg.Level)
+	assert.Equal(t, int64(1617763620123), msg.Time)
+	assert.Equal(t, `[region.go:176] ["region 2 acquire 1 locks takes 60ms"]`, msg.Message)
+
+	_, ok = parseLogLine("goroutine 1 [running]:")
+	assert.False(t, ok)
+
+	req := &diagnosticspb.SearchLogRequest{Levels: []diagnosticspb.LogLevel{diagnosticspb.LogLevel_Warn}}
+	assert.True(t, matchLogMessage(req, []*regexp.Regexp{regexp.MustCompile("(?i)ACQUIRE")}, msg))
+	assert.False(t, matchLogMessage(req, []*regexp.Regexp{regexp.MustCompile("(?i)split")}, msg))
+	assert.False(t, matchLogMessage(req, []*regexp.Regexp{regexp.MustCompile("(?i)warn")}, msg))
+	req.StartTime = msg.Time + 1
+	assert.False(t, matchLogMessage(req, nil, msg))
+}
@@ -0,0 +1,85 @@
+// Copyright 2019-present PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ngaut/unistore/config"
+	"github.com/pingcap/log"
+	"github.com/pingcap/tidb/store/mockstore/unistore/pd"
+	"github.com/pingcap/tidb/store/mockstore/unistore/tikv"
+	"go.uber.org/zap"
+)
+
+// gcWorker follows the GC safe point in PD and advances the safe point of the store, so the compaction
+// filter drops the old versions without relying on KvGC requests. The safe point in PD is only advanced
+// after the expired locks are resolved by TiDB's GC, so the worker doesn't resolve locks itself.
+type gcWorker struct {
+	pdClient        pd.Client
+	interval        time.Duration
+	updateSafePoint func(safePoint uint64)
+	safePoint       uint64
+}
+
+// startGCWorker starts the GC worker if it is enabled, the worker exits when closeCh is closed, and the
+// returned WaitGroup is done after the worker exits.
+func startGCWorker(pdClient pd.Client, store *tikv.MVCCStore, conf *config.Config, closeCh <-chan struct{}) *sync.WaitGroup {
+	wg := new(sync.WaitGroup)
+	interval := config.ParseDuration(conf.GC.SafePointCheckInterval)
+	if interval == 0 {
+		return wg
+	}
+	w := &gcWorker{
+		pdClient:        pdClient,
+		interval:        interval,
+		updateSafePoint: store.UpdateSafePoint,
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		w.run(closeCh)
+	}()
+	return wg
+}
+
+func (w *gcWorker) run(closeCh <-chan struct{}) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			w.tick()
+		case <-closeCh:
+			return
+		}
+	}
+}
+
+func (w *gcWorker) tick() {
+	ctx, cancel := context.WithTimeout(context.Background(), w.interval)
+	safePoint, err := w.pdClient.GetGCSafePoint(ctx)
+	cancel()
+	if err != nil {
+		log.Warn("failed to get GC safe point", zap.Error(err))
+		return
+	}
+	if safePoint <= w.safePoint {
+		return
+	}
+	w.safePoint = safePoint
+	w.updateSafePoint(safePoint)
+}
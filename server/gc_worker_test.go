@@ -0,0 +1,83 @@
+// Copyright 2019-present PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/tidb/store/mockstore/unistore/pd"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockSafePointClient struct {
+	pd.Client
+	safePoint uint64
+	err       error
+}
+
+func (c *mockSafePointClient) GetGCSafePoint(ctx context.Context) (uint64, error) {
+	return c.safePoint, c.err
+}
+
+func TestGCWorkerTick(t *testing.T) {
+	client := &mockSafePointClient{safePoint: 100}
+	var updated []uint64
+	w := &gcWorker{
+		pdClient: client,
+		interval: time.Second,
+		updateSafePoint: func(safePoint uint64) {
+			updated = append(updated, safePoint)
+		},
+	}
+	w.tick()
+	// The safe point is not changed.
+	w.tick()
+	client.err = errors.New("pd is unavailable")
+	client.safePoint = 200
+	w.tick()
+	client.err = nil
+	w.tick()
+	// The safe point never goes back.
+	client.safePoint = 150
+	w.tick()
+	assert.Equal(t, []uint64{100, 200}, updated)
+}
+
+func TestGCWorkerStop(t *testing.T) {
+	client := &mockSafePointClient{safePoint: 100}
+	updated := make(chan uint64, 1)
+	w := &gcWorker{
+		pdClient: client,
+		interval: time.Millisecond,
+		updateSafePoint: func(safePoint uint64) {
+			updated <- safePoint
+		},
+	}
+	closeCh := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		w.run(closeCh)
+		close(done)
+	}()
+	assert.Equal(t, uint64(100), <-updated)
+	close(closeCh)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("the GC worker doesn't exit")
+	}
+}
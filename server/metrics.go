@@ -0,0 +1,113 @@
+// Copyright 2019-present PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"strconv"
+	"sync"
+
+	"github.com/ngaut/unistore/raftstore"
+	"github.com/pingcap/badger"
+	tidbconfig "github.com/pingcap/tidb/store/mockstore/unistore/config"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	engineLevelTablesDesc = prometheus.NewDesc(
+		"unistore_engine_level_tables",
+		"Number of tables in each level of the engine.",
+		[]string{"engine", "level"}, nil)
+	engineSizeDesc = prometheus.NewDesc(
+		"unistore_engine_size_bytes",
+		"Size of the LSM tree and the value log of the engine.",
+		[]string{"engine", "type"}, nil)
+	engineWriteStallDesc = prometheus.NewDesc(
+		"unistore_engine_write_stall",
+		"Whether the level 0 tables of the engine reach the write stall threshold.",
+		[]string{"engine"}, nil)
+)
+
+// engineMetrics is the only engineCollector of the process, a server registers its DBs when it starts and
+// unregisters them when it stops, so a process can start the server more than once.
+var engineMetrics = newEngineCollector()
+
+func init() {
+	prometheus.MustRegister(engineMetrics)
+}
+
+// engineCollector reports the metrics of the registered badger DBs when scraped, the DBs are told apart by
+// the engine label.
+type engineCollector struct {
+	mu      sync.Mutex
+	engines map[string]*engineMetricsSource
+}
+
+type engineMetricsSource struct {
+	db  *badger.DB
+	cfg *tidbconfig.Engine
+}
+
+func newEngineCollector() *engineCollector {
+	return &engineCollector{engines: make(map[string]*engineMetricsSource)}
+}
+
+// register adds the DB as the engine name, it replaces the DB registered before with the same name.
+func (c *engineCollector) register(name string, db *badger.DB, cfg *tidbconfig.Engine) {
+	c.mu.Lock()
+	c.engines[name] = &engineMetricsSource{db: db, cfg: cfg}
+	c.mu.Unlock()
+}
+
+// unregister removes the engine name if it is still the DB.
+func (c *engineCollector) unregister(name string, db *badger.DB) {
+	c.mu.Lock()
+	if source, ok := c.engines[name]; ok && source.db == db {
+		delete(c.engines, name)
+	}
+	c.mu.Unlock()
+}
+
+// Describe implements the prometheus.Collector Describe method.
+func (c *engineCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- engineLevelTablesDesc
+	ch <- engineSizeDesc
+	ch <- engineWriteStallDesc
+}
+
+// Collect implements the prometheus.Collector Collect method.
+func (c *engineCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for name, source := range c.engines {
+		levelTables := make([]int, badger.DefaultOptions.TableBuilderOptions.MaxLevels)
+		for _, table := range source.db.Tables() {
+			for table.Level >= len(levelTables) {
+				levelTables = append(levelTables, 0)
+			}
+			levelTables[table.Level]++
+		}
+		for level, n := range levelTables {
+			ch <- prometheus.MustNewConstMetric(engineLevelTablesDesc, prometheus.GaugeValue, float64(n),
+				name, strconv.Itoa(level))
+		}
+		lsmSize, vlogSize := source.db.Size()
+		ch <- prometheus.MustNewConstMetric(engineSizeDesc, prometheus.GaugeValue, float64(lsmSize), name, "lsm")
+		ch <- prometheus.MustNewConstMetric(engineSizeDesc, prometheus.GaugeValue, float64(vlogSize), name, "vlog")
+		var stall float64
+		if raftstore.L0Stalled(source.cfg, levelTables[0]) {
+			stall = 1
+		}
+		ch <- prometheus.MustNewConstMetric(engineWriteStallDesc, prometheus.GaugeValue, stall, name)
+	}
+}
@@ -0,0 +1,165 @@
+// Copyright 2019-present PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ngaut/unistore/config"
+	"github.com/pingcap/badger"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/kvproto/pkg/pdpb"
+	"github.com/pingcap/tidb/store/mockstore/unistore/lockstore"
+	"github.com/pingcap/tidb/store/mockstore/unistore/tikv"
+	"github.com/pingcap/tidb/store/mockstore/unistore/tikv/mvcc"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func metricLabels(m *dto.Metric) map[string]string {
+	result := make(map[string]string)
+	for _, pair := range m.GetLabel() {
+		result[pair.GetName()] = pair.GetValue()
+	}
+	return result
+}
+
+// gatherEngineMetrics returns the metrics reported by the engine collector, grouped by name and engine.
+func gatherEngineMetrics(t *testing.T, gatherer prometheus.Gatherer) map[string]map[string][]*dto.Metric {
+	families, err := gatherer.Gather()
+	require.Nil(t, err)
+	result := make(map[string]map[string][]*dto.Metric)
+	for _, family := range families {
+		switch family.GetName() {
+		case "unistore_engine_level_tables", "unistore_engine_size_bytes", "unistore_engine_write_stall":
+		default:
+			continue
+		}
+		byEngine := make(map[string][]*dto.Metric)
+		for _, m := range family.GetMetric() {
+			engine := metricLabels(m)["engine"]
+			byEngine[engine] = append(byEngine[engine], m)
+		}
+		result[family.GetName()] = byEngine
+	}
+	return result
+}
+
+func openTestDB(t *testing.T, dir string) *badger.DB {
+	opts := badger.DefaultOptions
+	opts.Dir = dir
+	opts.ValueDir = dir
+	db, err := badger.Open(opts)
+	require.Nil(t, err)
+	return db
+}
+
+func TestEngineCollector(t *testing.T) {
+	dir, err := ioutil.TempDir("", "engine_collector")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+	db := openTestDB(t, dir)
+	defer db.Close()
+
+	collector := newEngineCollector()
+	registry := prometheus.NewRegistry()
+	require.Nil(t, registry.Register(collector))
+	engineConf := config.DefaultConf.Engine
+	collector.register(subPathKV, db, &engineConf)
+	collector.register(subPathRaft, db, &engineConf)
+
+	metrics := gatherEngineMetrics(t, registry)
+	for _, engine := range []string{subPathKV, subPathRaft} {
+		levels := metrics["unistore_engine_level_tables"][engine]
+		assert.Len(t, levels, badger.DefaultOptions.TableBuilderOptions.MaxLevels)
+		var total float64
+		for _, m := range levels {
+			total += m.GetGauge().GetValue()
+		}
+		assert.Equal(t, float64(len(db.Tables())), total)
+		assert.Len(t, metrics["unistore_engine_size_bytes"][engine], 2)
+		require.Len(t, metrics["unistore_engine_write_stall"][engine], 1)
+		assert.Equal(t, float64(0), metrics["unistore_engine_write_stall"][engine][0].GetGauge().GetValue())
+	}
+
+	// The raft engine is registered again by another DB, unregistering the old one is a no-op.
+	collector.unregister(subPathRaft, nil)
+	collector.unregister(subPathKV, db)
+	metrics = gatherEngineMetrics(t, registry)
+	assert.NotContains(t, metrics["unistore_engine_write_stall"], subPathKV)
+	assert.Contains(t, metrics["unistore_engine_write_stall"], subPathRaft)
+}
+
+// raftTestPD implements the MockPD methods used by raftstore that MockPD leaves unimplemented.
+type raftTestPD struct {
+	*tikv.MockPD
+}
+
+func (pd raftTestPD) SetRegionHeartbeatResponseHandler(h func(*pdpb.RegionHeartbeatResponse)) {}
+
+func (pd raftTestPD) AskBatchSplit(ctx context.Context, region *metapb.Region, count int) (*pdpb.AskBatchSplitResponse, error) {
+	resp := new(pdpb.AskBatchSplitResponse)
+	for i := 0; i < count; i++ {
+		id := new(pdpb.SplitID)
+		var err error
+		if id.NewRegionId, err = pd.AllocID(ctx); err != nil {
+			return nil, err
+		}
+		for range region.Peers {
+			peerID, err := pd.AllocID(ctx)
+			if err != nil {
+				return nil, err
+			}
+			id.NewPeerIds = append(id.NewPeerIds, peerID)
+		}
+		resp.Ids = append(resp.Ids, id)
+	}
+	return resp, nil
+}
+
+func (pd raftTestPD) ReportBatchSplit(ctx context.Context, regions []*metapb.Region) error {
+	return nil
+}
+
+func TestStartServerTwice(t *testing.T) {
+	for i := 0; i < 2; i++ {
+		dir, err := ioutil.TempDir("", "start_server")
+		require.Nil(t, err)
+		pdDir := filepath.Join(dir, "pd")
+		require.Nil(t, os.Mkdir(pdDir, 0755))
+		pdDB := openTestDB(t, pdDir)
+		rm, err := tikv.NewMockRegionManager(&mvcc.DBBundle{DB: pdDB, LockStore: lockstore.NewMemStore(4096)}, 1, tikv.RegionOptions{})
+		require.Nil(t, err)
+		pdClient := raftTestPD{tikv.NewMockPD(rm)}
+		conf := config.DefaultConf
+		conf.Server.Raft = true
+		conf.Server.StoreAddr = "127.0.0.1:0"
+		conf.Engine.DBPath = dir
+		server, err := New(&conf, pdClient)
+		require.Nil(t, err)
+		metrics := gatherEngineMetrics(t, prometheus.DefaultGatherer)
+		assert.Contains(t, metrics["unistore_engine_write_stall"], subPathKV)
+		assert.Contains(t, metrics["unistore_engine_write_stall"], subPathRaft)
+		server.Stop()
+		assert.Empty(t, gatherEngineMetrics(t, prometheus.DefaultGatherer)["unistore_engine_write_stall"])
+		pdDB.Close()
+		os.RemoveAll(dir)
+	}
+}
@@ -5,11 +5,13 @@ import (
 	"encoding/binary"
 	"os"
 	"path/filepath"
+	"sort"
 
 	"github.com/ngaut/unistore/config"
 	"github.com/ngaut/unistore/raftstore"
 	"github.com/pingcap/badger"
 	"github.com/pingcap/badger/options"
+	"github.com/pingcap/kvproto/pkg/kvrpcpb"
 	tidbconfig "github.com/pingcap/tidb/store/mockstore/unistore/config"
 	"github.com/pingcap/tidb/store/mockstore/unistore/lockstore"
 	"github.com/pingcap/tidb/store/mockstore/unistore/pd"
@@ -22,8 +24,23 @@ const (
 	subPathKV   = "kv"
 )
 
-// New returns a new tikv.Server.
-func New(conf *config.Config, pdClient pd.Client) (*tikv.Server, error) {
+// Server is a tikv.Server that destroys the whole range in UnsafeDestroyRange.
+type Server struct {
+	*tikv.Server
+	bundle *mvcc.DBBundle
+}
+
+// UnsafeDestroyRange implements the tikvpb.TikvServer UnsafeDestroyRange method. tikv.Server only drops the
+// tables that lie entirely inside the range, the keys and locks in the partly covered tables are deleted too.
+func (s *Server) UnsafeDestroyRange(ctx context.Context, req *kvrpcpb.UnsafeDestroyRangeRequest) (*kvrpcpb.UnsafeDestroyRangeResponse, error) {
+	if err := raftstore.UnsafeDestroyRange(s.bundle, req.GetStartKey(), req.GetEndKey()); err != nil {
+		return &kvrpcpb.UnsafeDestroyRangeResponse{Error: err.Error()}, nil
+	}
+	return &kvrpcpb.UnsafeDestroyRangeResponse{}, nil
+}
+
+// New returns a new Server.
+func New(conf *config.Config, pdClient pd.Client) (*Server, error) {
 	physical, logical, err := pdClient.GetTS(context.Background())
 	if err != nil {
 		return nil, err
@@ -40,12 +57,17 @@ func New(conf *config.Config, pdClient pd.Client) (*tikv.Server, error) {
 		LockStore: lockstore.NewMemStore(8 << 20),
 		StateTS:   ts,
 	}
+	var svr *tikv.Server
 	if conf.Server.Raft {
-		return setupRaftServer(bundle, safePoint, pdClient, conf)
+		svr, err = setupRaftServer(bundle, safePoint, pdClient, conf)
+	} else {
+		rm := tikv.NewStandAloneRegionManager(bundle, getRegionOptions(conf), pdClient)
+		svr, err = setupStandAlongInnerServer(bundle, safePoint, rm, pdClient, conf)
 	}
-
-	rm := tikv.NewStandAloneRegionManager(bundle, getRegionOptions(conf), pdClient)
-	return setupStandAlongInnerServer(bundle, safePoint, rm, pdClient, conf)
+	if err != nil {
+		return nil, err
+	}
+	return &Server{Server: svr, bundle: bundle}, nil
 }
 
 func getRegionOptions(conf *config.Config) tikv.RegionOptions {
@@ -108,8 +130,17 @@ func setupRaftServer(bundle *mvcc.DBBundle, safePoint *tikv.SafePoint, pdClient
 	}
 
 	store.StartDeadlockDetection(true)
-
-	return tikv.NewServer(rm, store, innerServer), nil
+	closeCh := make(chan struct{})
+	gcWG := startGCWorker(pdClient, store, conf, closeCh)
+
+	inner := &stoppableInnerServer{InnerServer: innerServer}
+	inner.onStop(func() {
+		close(closeCh)
+		gcWG.Wait()
+	})
+	registerEngineMetrics(inner, subPathKV, bundle.DB, &conf.Engine)
+	registerEngineMetrics(inner, subPathRaft, raftDB, &conf.Engine)
+	return tikv.NewServer(rm, store, inner), nil
 }
 
 func setupStandAlongInnerServer(bundle *mvcc.DBBundle, safePoint *tikv.SafePoint, rm tikv.RegionManager, pdClient pd.Client, conf *config.Config) (*tikv.Server, error) {
@@ -123,8 +154,42 @@ func setupStandAlongInnerServer(bundle *mvcc.DBBundle, safePoint *tikv.SafePoint
 	}
 
 	store.StartDeadlockDetection(false)
+	closeCh := make(chan struct{})
+	gcWG := startGCWorker(pdClient, store, conf, closeCh)
+
+	inner := &stoppableInnerServer{InnerServer: innerServer}
+	inner.onStop(func() {
+		close(closeCh)
+		gcWG.Wait()
+	})
+	registerEngineMetrics(inner, subPathKV, bundle.DB, &conf.Engine)
+	return tikv.NewServer(rm, store, inner), nil
+}
+
+// stoppableInnerServer runs the stop hooks before stopping the inner server, tikv.Server stops the inner
+// server last when it stops.
+type stoppableInnerServer struct {
+	tikv.InnerServer
+	stopHooks []func()
+}
+
+func (s *stoppableInnerServer) onStop(hook func()) {
+	s.stopHooks = append(s.stopHooks, hook)
+}
 
-	return tikv.NewServer(rm, store, innerServer), nil
+// Stop implements the tikv.InnerServer Stop method.
+func (s *stoppableInnerServer) Stop() error {
+	for _, hook := range s.stopHooks {
+		hook()
+	}
+	return s.InnerServer.Stop()
+}
+
+func registerEngineMetrics(inner *stoppableInnerServer, name string, db *badger.DB, conf *tidbconfig.Engine) {
+	engineMetrics.register(name, db, conf)
+	inner.onStop(func() {
+		engineMetrics.unregister(name, db)
+	})
 }
 
 func setupRaftStoreConf(raftConf *raftstore.Config, conf *config.Config) {
@@ -136,6 +201,13 @@ func setupRaftStoreConf(raftConf *raftstore.Config, conf *config.Config) {
 	raftConf.RaftBaseTickInterval = config.ParseDuration(conf.RaftStore.RaftBaseTickInterval)
 	raftConf.RaftHeartbeatTicks = conf.RaftStore.RaftHeartbeatTicks
 	raftConf.RaftElectionTimeoutTicks = conf.RaftStore.RaftElectionTimeoutTicks
+	raftConf.Labels = raftConf.Labels[:0]
+	for key, value := range conf.RaftStore.Labels {
+		raftConf.Labels = append(raftConf.Labels, raftstore.StoreLabel{LabelKey: key, LabelValue: value})
+	}
+	sort.Slice(raftConf.Labels, func(i, j int) bool {
+		return raftConf.Labels[i].LabelKey < raftConf.Labels[j].LabelKey
+	})
 
 	// coprocessor block
 	raftConf.SplitCheck.RegionMaxKeys = uint64(conf.Coprocessor.RegionMaxKeys)
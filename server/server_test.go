@@ -0,0 +1,39 @@
+// Copyright 2019-present PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"testing"
+
+	"github.com/BurntSushi/toml"
+	"github.com/ngaut/unistore/config"
+	"github.com/ngaut/unistore/raftstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetupRaftStoreLabels(t *testing.T) {
+	conf := config.DefaultConf
+	_, err := toml.Decode(`
+[raftstore]
+labels = { zone = "z1", host = "h1" }
+`, &conf)
+	require.Nil(t, err)
+	raftConf := raftstore.NewDefaultConfig()
+	setupRaftStoreConf(raftConf, &conf)
+	assert.Equal(t, []raftstore.StoreLabel{
+		{LabelKey: "host", LabelValue: "h1"},
+		{LabelKey: "zone", LabelValue: "z1"},
+	}, raftConf.Labels)
+}
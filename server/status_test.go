@@ -0,0 +1,70 @@
+// Copyright 2019-present PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ngaut/unistore/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatusHandler(t *testing.T) {
+	dir, err := ioutil.TempDir("", "status")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+	require.Nil(t, os.MkdirAll(filepath.Join(dir, subPathKV), 0755))
+	require.Nil(t, ioutil.WriteFile(filepath.Join(dir, subPathKV, "000001.sst"), make([]byte, 10), 0644))
+
+	conf := config.DefaultConf
+	conf.Engine.DBPath = dir
+	server := httptest.NewServer(NewStatusHandler(&conf))
+	defer server.Close()
+
+	get := func(path string) (int, []byte) {
+		resp, err := http.Get(server.URL + path)
+		require.Nil(t, err)
+		defer resp.Body.Close()
+		body, err := ioutil.ReadAll(resp.Body)
+		require.Nil(t, err)
+		return resp.StatusCode, body
+	}
+
+	code, _ := get("/status")
+	assert.Equal(t, http.StatusOK, code)
+
+	code, body := get("/config")
+	assert.Equal(t, http.StatusOK, code)
+	var got config.Config
+	require.Nil(t, json.Unmarshal(body, &got))
+	assert.Equal(t, dir, got.Engine.DBPath)
+
+	code, body = get("/engine")
+	assert.Equal(t, http.StatusOK, code)
+	var engines []engineStatus
+	require.Nil(t, json.Unmarshal(body, &engines))
+	assert.Equal(t, []engineStatus{{Name: subPathKV, Size: 10, Files: 1}}, engines)
+
+	code, _ = get("/metrics")
+	assert.Equal(t, http.StatusOK, code)
+	code, _ = get("/debug/pprof/")
+	assert.Equal(t, http.StatusOK, code)
+}